@@ -0,0 +1,362 @@
+package dbx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/microbun/dbx/introspect"
+	"github.com/microbun/dbx/reflectx"
+)
+
+// AutoMigrateOptions changes the behaviour of AutoMigrateWith.
+type AutoMigrateOptions struct {
+	// DryRun returns the DDL statements without executing them.
+	DryRun bool
+	// Destructive drops the columns and the indexes of the tables which are not declared by the structs,
+	// nothing is dropped otherwise.
+	Destructive bool
+}
+
+// AutoMigrate creates the missing tables of models and adds their missing columns and indexes.
+// A model is a pointer to a struct implementing Table or TableContext, its columns are the fields
+// with a dbx column. Besides column, primary_key and auto_increment the tag may contain:
+//
+//	type:<type>        the column type instead of the type mapped from the field type
+//	size:<n>           the length of a varchar column
+//	not_null           a NOT NULL column
+//	default:<expr>     the DEFAULT expression of the column
+//	index              an index on the column
+//	unique             an unique index on the column
+//
+// The existing columns are never altered and nothing is dropped, see AutoMigrateWith.
+func (d *DB) AutoMigrate(ctx context.Context, models ...interface{}) error {
+	_, err := d.AutoMigrateWith(ctx, AutoMigrateOptions{}, models...)
+	return err
+}
+
+// AutoMigrateWith is AutoMigrate with options, it returns the DDL statements in the order they are executed.
+func (d *DB) AutoMigrateWith(ctx context.Context, opts AutoMigrateOptions, models ...interface{}) ([]string, error) {
+	var statements []string
+	for _, model := range models {
+		ddl, err := d.migrateDDL(ctx, model, opts.Destructive)
+		if err != nil {
+			return statements, err
+		}
+		for _, s := range ddl {
+			if !opts.DryRun {
+				if _, err = d.ExecContext(ctx, s); err != nil {
+					return statements, err
+				}
+			}
+			statements = append(statements, s)
+		}
+	}
+	return statements, nil
+}
+
+// ModelTable is the table declared by a model, see AutoMigrate.
+type ModelTable struct {
+	// Name is the table name resolved like Insert, it may be qualified by a schema.
+	Name    string
+	Columns []ModelColumn
+	Indexes []ModelIndex
+}
+
+// ModelColumn is a column declared by a field of a model.
+type ModelColumn struct {
+	Name string
+	// Type is the column type of the dialect, from the tag or mapped from the field type.
+	Type string
+	// NotNull is set by the not_null option or for a primary key.
+	NotNull       bool
+	PrimaryKey    bool
+	AutoIncrement bool
+	Default       string
+}
+
+// ModelIndex is an index declared by the index or unique option of a field.
+type ModelIndex struct {
+	Name   string
+	Column string
+	Unique bool
+}
+
+// DescribeModel returns the table declared by the tags of model with the column types of the dialect of the database.
+func (d *DB) DescribeModel(ctx context.Context, model interface{}) (*ModelTable, error) {
+	t := reflect.TypeOf(model)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("model must be a pointer to struct")
+	}
+	table := &ModelTable{Name: d.option.structTable(ctx, model)}
+	if table.Name == "" {
+		return nil, fmt.Errorf("%s not implement Table interface", t.Elem())
+	}
+	fields := reflectx.TypeFields(t.Elem())
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("%s has no dbx column", t.Elem())
+	}
+	_, name := splitTable(table.Name)
+	name = unquote(name)
+	for _, f := range fields {
+		typ := f.Tag.Type
+		if typ == "" {
+			var ok bool
+			if typ, ok = d.option.columnType(f); !ok {
+				return nil, fmt.Errorf("column `%s`: no column type for %s, add a type to the tag", f.Tag.Column, f.Type)
+			}
+		}
+		table.Columns = append(table.Columns, ModelColumn{
+			Name:          f.Tag.Column,
+			Type:          typ,
+			NotNull:       f.Tag.NotNull || f.Tag.PrimaryKey,
+			PrimaryKey:    f.Tag.PrimaryKey,
+			AutoIncrement: f.Tag.AutoIncrement,
+			Default:       f.Tag.Default,
+		})
+		if f.Tag.Unique {
+			table.Indexes = append(table.Indexes, ModelIndex{Name: "uk_" + name + "_" + f.Tag.Column, Column: f.Tag.Column, Unique: true})
+		} else if f.Tag.Index {
+			table.Indexes = append(table.Indexes, ModelIndex{Name: "idx_" + name + "_" + f.Tag.Column, Column: f.Tag.Column})
+		}
+	}
+	return table, nil
+}
+
+// migrateDDL returns the statements migrating the table of model.
+func (d *DB) migrateDDL(ctx context.Context, model interface{}, destructive bool) ([]string, error) {
+	m, err := d.DescribeModel(ctx, model)
+	if err != nil {
+		return nil, err
+	}
+	t, err := d.liveTable(ctx, m.Name)
+	if err != nil {
+		return nil, err
+	}
+	var ddl []string
+	if t == nil {
+		ddl = append(ddl, d.option.createTableSQL(m))
+		for _, idx := range m.Indexes {
+			ddl = append(ddl, d.option.createIndexSQL(m.Name, idx))
+		}
+		return ddl, nil
+	}
+
+	var columns, existing []string
+	for _, c := range t.Columns {
+		columns = append(columns, c.Name)
+	}
+	for _, idx := range t.Indexes {
+		existing = append(existing, idx.Name)
+	}
+	declared := map[string]bool{}
+	for _, c := range m.Columns {
+		declared[c.Name] = true
+		if !contains(columns, c.Name) {
+			ddl = append(ddl, fmt.Sprintf("alter table %s add column %s", d.option.quoteTable(m.Name), d.option.columnDefinition(c, false)))
+		}
+	}
+	declaredIndexes := map[string]bool{}
+	for _, idx := range m.Indexes {
+		declaredIndexes[idx.Name] = true
+		if !contains(existing, idx.Name) {
+			ddl = append(ddl, d.option.createIndexSQL(m.Name, idx))
+		}
+	}
+	if destructive {
+		// the indexes are dropped first, SQLite can't drop an indexed column.
+		for _, idx := range existing {
+			if !declaredIndexes[idx] && !isImplicitIndex(idx) {
+				ddl = append(ddl, d.option.dropIndexSQL(m.Name, idx))
+			}
+		}
+		for _, c := range columns {
+			if !declared[c] {
+				ddl = append(ddl, fmt.Sprintf("alter table %s drop column %s", d.option.quoteTable(m.Name), d.option.quote(c)))
+			}
+		}
+	}
+	return ddl, nil
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// isImplicitIndex reports whether the index is created by SQLite for an unique constraint.
+func isImplicitIndex(name string) bool {
+	return strings.HasPrefix(name, "sqlite_autoindex_")
+}
+
+func (o *Options) quote(identifier string) string {
+	if o.Dialect != nil {
+		return o.Dialect.Quote(identifier)
+	}
+	return MySQL.Quote(identifier)
+}
+
+func (o *Options) sqlite() bool {
+	return o.Dialect == SQLite
+}
+
+func (o *Options) createTableSQL(m *ModelTable) string {
+	var definitions, primaryKeys []string
+	for _, c := range m.Columns {
+		if c.PrimaryKey {
+			primaryKeys = append(primaryKeys, o.quote(c.Name))
+		}
+	}
+	inline := false
+	for _, c := range m.Columns {
+		// SQLite declares an auto increment primary key in the column definition.
+		pk := o.sqlite() && c.PrimaryKey && c.AutoIncrement && len(primaryKeys) == 1
+		inline = inline || pk
+		definitions = append(definitions, o.columnDefinition(c, pk))
+	}
+	if len(primaryKeys) > 0 && !inline {
+		definitions = append(definitions, "primary key ("+strings.Join(primaryKeys, ", ")+")")
+	}
+	return fmt.Sprintf("create table %s (\n\t%s\n)", o.quoteTable(m.Name), strings.Join(definitions, ",\n\t"))
+}
+
+// columnDefinition returns the definition of the column c,
+// primaryKey declares an inline auto increment primary key of SQLite.
+func (o *Options) columnDefinition(c ModelColumn, primaryKey bool) string {
+	def := o.quote(c.Name)
+	if primaryKey {
+		return def + " integer primary key autoincrement"
+	}
+	def += " " + c.Type
+	if c.NotNull {
+		def += " not null"
+	}
+	if c.AutoIncrement && !o.sqlite() {
+		def += " auto_increment"
+	}
+	if c.Default != "" {
+		def += " default " + c.Default
+	}
+	return def
+}
+
+// columnType maps the type of the field to a column type of the dialect.
+func (o *Options) columnType(f reflectx.Field) (string, bool) {
+	t := f.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	varchar := "text"
+	if f.Tag.Size > 0 || !o.sqlite() {
+		size := f.Tag.Size
+		if size <= 0 {
+			size = 255
+		}
+		varchar = fmt.Sprintf("varchar(%d)", size)
+	}
+	switch t.PkgPath() + "." + t.Name() {
+	case "time.Time", "database/sql.NullTime":
+		return "datetime", true
+	case "database/sql.NullString":
+		return varchar, true
+	case "database/sql.NullInt64":
+		return o.typeOf("bigint", "integer"), true
+	case "database/sql.NullInt32":
+		return o.typeOf("int", "integer"), true
+	case "database/sql.NullBool":
+		return o.typeOf("tinyint(1)", "boolean"), true
+	case "database/sql.NullFloat64":
+		return o.typeOf("double", "real"), true
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return o.typeOf("tinyint(1)", "boolean"), true
+	case reflect.Int8:
+		return o.typeOf("tinyint", "integer"), true
+	case reflect.Int16:
+		return o.typeOf("smallint", "integer"), true
+	case reflect.Int32:
+		return o.typeOf("int", "integer"), true
+	case reflect.Int, reflect.Int64:
+		return o.typeOf("bigint", "integer"), true
+	case reflect.Uint8:
+		return o.typeOf("tinyint unsigned", "integer"), true
+	case reflect.Uint16:
+		return o.typeOf("smallint unsigned", "integer"), true
+	case reflect.Uint32:
+		return o.typeOf("int unsigned", "integer"), true
+	case reflect.Uint, reflect.Uint64:
+		return o.typeOf("bigint unsigned", "integer"), true
+	case reflect.Float32:
+		return o.typeOf("float", "real"), true
+	case reflect.Float64:
+		return o.typeOf("double", "real"), true
+	case reflect.String:
+		return varchar, true
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "blob", true
+		}
+	}
+	return "", false
+}
+
+// typeOf returns the MySQL type, or the SQLite type for SQLite.
+func (o *Options) typeOf(mysql, sqlite string) string {
+	if o.sqlite() {
+		return sqlite
+	}
+	return mysql
+}
+
+func (o *Options) createIndexSQL(table string, idx ModelIndex) string {
+	unique := ""
+	if idx.Unique {
+		unique = "unique "
+	}
+	return fmt.Sprintf("create %sindex %s on %s (%s)", unique, o.quote(idx.Name), o.quoteTable(table), o.quote(idx.Column))
+}
+
+func (o *Options) dropIndexSQL(table string, name string) string {
+	if o.sqlite() {
+		if schema, _ := splitTable(table); schema != "" {
+			return "drop index " + o.quoteTable(schema) + "." + o.quote(name)
+		}
+		return "drop index " + o.quote(name)
+	}
+	return fmt.Sprintf("drop index %s on %s", o.quote(name), o.quoteTable(table))
+}
+
+// splitTable splits a table name qualified by a schema on the last dot which is not quoted,
+// the schema is empty if it is not qualified.
+func splitTable(table string) (schema string, name string) {
+	parts := tableParts(table)
+	return strings.Join(parts[:len(parts)-1], "."), parts[len(parts)-1]
+}
+
+// unquote removes the quotes of a quoted identifier.
+func unquote(identifier string) string {
+	if len(identifier) < 2 {
+		return identifier
+	}
+	q := identifier[0]
+	if (q == '`' || q == '"') && identifier[len(identifier)-1] == q {
+		return strings.Replace(identifier[1:len(identifier)-1], string([]byte{q, q}), string(q), -1)
+	}
+	return identifier
+}
+
+// liveTable returns the table of the database, nil if it doesn't exist.
+// ErrUnsupportedDialect is returned for a database without a dialect.
+func (d *DB) liveTable(ctx context.Context, table string) (*introspect.Table, error) {
+	if d.option.Dialect == nil {
+		return nil, ErrUnsupportedDialect
+	}
+	return introspect.LoadTable(ctx, d.RawDB(), d.option.Dialect.Name(), table)
+}
@@ -0,0 +1,130 @@
+package dbx
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type migrateUser struct {
+	ID        int64          `dbx:"column:id;primary_key;auto_increment"`
+	Email     string         `dbx:"column:email;size:128;not_null;unique"`
+	Name      sql.NullString `dbx:"column:name;index"`
+	Score     float64        `dbx:"column:score;default:0"`
+	CreatedAt time.Time      `dbx:"column:created_at"`
+}
+
+func (*migrateUser) TableName() string {
+	return "users"
+}
+
+type migrateUserV2 struct {
+	ID    int64  `dbx:"column:id;primary_key;auto_increment"`
+	Email string `dbx:"column:email;size:128;not_null;unique"`
+	Level *int   `dbx:"column:level;type:smallint;index"`
+}
+
+func (*migrateUserV2) TableName() string {
+	return "users"
+}
+
+func TestDB_AutoMigrate(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	ddl, err := db.AutoMigrateWith(ctx, AutoMigrateOptions{DryRun: true}, &migrateUser{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"create table \"users\" (\n\t\"id\" integer primary key autoincrement,\n\t\"email\" varchar(128) not null,\n\t\"name\" text,\n\t\"score\" real default 0,\n\t\"created_at\" datetime\n)",
+		`create unique index "uk_users_email" on "users" ("email")`,
+		`create index "idx_users_name" on "users" ("name")`,
+	}
+	if !reflect.DeepEqual(ddl, want) {
+		t.Fatalf("dry run ddl=%q", ddl)
+	}
+	if table, _ := db.liveTable(ctx, "users"); table != nil {
+		t.Fatal("dry run created the table")
+	}
+	if err = db.AutoMigrate(ctx, &migrateUser{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = db.Insert(&migrateUser{Email: "a@b.c"}); err != nil {
+		t.Fatal(err)
+	}
+	if ddl, err = db.AutoMigrateWith(ctx, AutoMigrateOptions{}, &migrateUser{}); err != nil || len(ddl) != 0 {
+		t.Fatalf("migrate again ddl=%q err=%v", ddl, err)
+	}
+
+	if ddl, err = db.AutoMigrateWith(ctx, AutoMigrateOptions{}, &migrateUserV2{}); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{
+		`alter table "users" add column "level" smallint`,
+		`create index "idx_users_level" on "users" ("level")`,
+	}
+	if !reflect.DeepEqual(ddl, want) {
+		t.Fatalf("add column ddl=%q", ddl)
+	}
+	table, _ := db.liveTable(ctx, "users")
+	if len(table.Columns) != 6 {
+		t.Errorf("columns=%v", table.Columns)
+	}
+
+	if ddl, err = db.AutoMigrateWith(ctx, AutoMigrateOptions{Destructive: true}, &migrateUserV2{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(ddl) != 4 || !strings.HasPrefix(ddl[0], `drop index "idx_users_name"`) {
+		t.Fatalf("destructive ddl=%q", ddl)
+	}
+	table, _ = db.liveTable(ctx, "users")
+	var columns []string
+	for _, c := range table.Columns {
+		columns = append(columns, c.Name)
+	}
+	if !reflect.DeepEqual(columns, []string{"id", "email", "level"}) {
+		t.Errorf("columns=%v", columns)
+	}
+}
+
+func TestOptions_createTableSQL(t *testing.T) {
+	o := &Options{Dialect: MySQL}
+	m, err := (&DB{option: o}).DescribeModel(WithTableName(context.Background(), "shop.users"), &migrateUser{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Indexes[0].Name != "uk_users_email" {
+		t.Errorf("index=%s", m.Indexes[0].Name)
+	}
+	ddl := o.createTableSQL(m)
+	want := "create table `shop`.`users` (\n\t`id` bigint not null auto_increment,\n\t`email` varchar(128) not null,\n\t`name` varchar(255),\n\t`score` double default 0,\n\t`created_at` datetime,\n\tprimary key (`id`)\n)"
+	if ddl != want {
+		t.Errorf("ddl=%s", ddl)
+	}
+}
+
+func Test_splitTable(t *testing.T) {
+	for _, tt := range []struct{ table, schema, name string }{
+		{"users", "", "users"},
+		{"shop.users", "shop", "users"},
+		{"`my.schema`.`order.v2`", "`my.schema`", "`order.v2`"},
+	} {
+		if schema, name := splitTable(tt.table); schema != tt.schema || name != tt.name {
+			t.Errorf("splitTable(%s)=%s,%s", tt.table, schema, name)
+		}
+	}
+	if name := unquote("`order.v2`"); name != "order.v2" {
+		t.Errorf("unquote=%s", name)
+	}
+}
+
+func TestDB_AutoMigrate_NoDialect(t *testing.T) {
+	db := openSQLite(t)
+	db.Options().Dialect = nil
+	if _, err := db.AutoMigrateWith(context.Background(), AutoMigrateOptions{DryRun: true}, &migrateUser{}); err != ErrUnsupportedDialect {
+		t.Fatalf("expected ErrUnsupportedDialect, got:%v", err)
+	}
+}
@@ -0,0 +1,238 @@
+package dbx
+
+import (
+	"context"
+	"database/sql"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultHealthCheckInterval is the interval between two pings of the replicas
+// when Options.HealthCheckInterval is zero.
+const DefaultHealthCheckInterval = 10 * time.Second
+
+// Replica is a read only database of a cluster opened by OpenCluster.
+type Replica struct {
+	// inFlight is the first field to be 64-bit aligned for atomic operations.
+	inFlight  int64
+	unhealthy int32
+	db        *sql.DB
+	cache     *stmtCache
+}
+
+// InFlight returns the number of reads currently executed by the replica.
+func (r *Replica) InFlight() int64 {
+	return atomic.LoadInt64(&r.inFlight)
+}
+
+// Healthy reports whether the replica answered the last ping.
+func (r *Replica) Healthy() bool {
+	return atomic.LoadInt32(&r.unhealthy) == 0
+}
+
+// RawDB return a raw sql.DB object
+func (r *Replica) RawDB() *sql.DB {
+	return r.db
+}
+
+// Balancer picks the replica executing a read.
+type Balancer interface {
+	// Pick returns one of replicas, it is called with the healthy replicas only and replicas is never empty.
+	Pick(replicas []*Replica) *Replica
+}
+
+type roundRobinBalancer struct {
+	next uint64
+}
+
+// NewRoundRobinBalancer returns a Balancer picking the replicas in turn, it is the default Balancer.
+func NewRoundRobinBalancer() Balancer {
+	return &roundRobinBalancer{}
+}
+
+func (b *roundRobinBalancer) Pick(replicas []*Replica) *Replica {
+	n := atomic.AddUint64(&b.next, 1) - 1
+	return replicas[n%uint64(len(replicas))]
+}
+
+type randomBalancer struct{}
+
+// NewRandomBalancer returns a Balancer picking a random replica.
+func NewRandomBalancer() Balancer {
+	return randomBalancer{}
+}
+
+func (randomBalancer) Pick(replicas []*Replica) *Replica {
+	return replicas[rand.Intn(len(replicas))]
+}
+
+type leastInFlightBalancer struct{}
+
+// NewLeastInFlightBalancer returns a Balancer picking the replica executing the fewest reads.
+func NewLeastInFlightBalancer() Balancer {
+	return leastInFlightBalancer{}
+}
+
+func (leastInFlightBalancer) Pick(replicas []*Replica) *Replica {
+	picked := replicas[0]
+	for _, r := range replicas[1:] {
+		if r.InFlight() < picked.InFlight() {
+			picked = r
+		}
+	}
+	return picked
+}
+
+type forcePrimaryContextKey struct{}
+
+// ForcePrimary returns a copy of ctx whose reads are executed by the primary of a cluster,
+// typically to read a row just written without replication lag.
+func ForcePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forcePrimaryContextKey{}, true)
+}
+
+func isForcePrimary(ctx context.Context) bool {
+	force, _ := ctx.Value(forcePrimaryContextKey{}).(bool)
+	return force
+}
+
+// replicaSet is the replicas of a cluster and the health check pinging them.
+type replicaSet struct {
+	option     *Options
+	replicas   []*Replica
+	roundRobin Balancer
+	stop       chan struct{}
+	stopOnce   sync.Once
+	wg         sync.WaitGroup
+}
+
+func newReplicaSet(option *Options, dbs []*sql.DB) *replicaSet {
+	s := &replicaSet{option: option, roundRobin: NewRoundRobinBalancer(), stop: make(chan struct{})}
+	for _, db := range dbs {
+		s.replicas = append(s.replicas, &Replica{db: db, cache: newStmtCache()})
+	}
+	s.wg.Add(1)
+	go s.healthCheck()
+	return s
+}
+
+// pick returns the replica executing a read, nil if the read must be executed by the primary.
+func (s *replicaSet) pick(ctx context.Context) *Replica {
+	if s == nil || isForcePrimary(ctx) {
+		return nil
+	}
+	healthy := make([]*Replica, 0, len(s.replicas))
+	for _, r := range s.replicas {
+		if r.Healthy() {
+			healthy = append(healthy, r)
+		}
+	}
+	if len(healthy) == 0 {
+		return nil
+	}
+	b := s.option.Balancer
+	if b == nil {
+		b = s.roundRobin
+	}
+	return b.Pick(healthy)
+}
+
+func (s *replicaSet) interval() time.Duration {
+	if s.option.HealthCheckInterval > 0 {
+		return s.option.HealthCheckInterval
+	}
+	return DefaultHealthCheckInterval
+}
+
+// healthCheck pings the replicas periodically until the set is closed.
+func (s *replicaSet) healthCheck() {
+	defer s.wg.Done()
+	for {
+		interval := s.interval()
+		timer := time.NewTimer(interval)
+		select {
+		case <-s.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		s.check(context.Background(), interval)
+	}
+}
+
+// check pings the replicas concurrently, each one within timeout,
+// a replica failing to answer is ejected until it answers again.
+func (s *replicaSet) check(ctx context.Context, timeout time.Duration) {
+	var wg sync.WaitGroup
+	for _, r := range s.replicas {
+		wg.Add(1)
+		go func(r *Replica) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			if err := r.db.PingContext(ctx); err != nil {
+				atomic.StoreInt32(&r.unhealthy, 1)
+				return
+			}
+			atomic.StoreInt32(&r.unhealthy, 0)
+		}(r)
+	}
+	wg.Wait()
+}
+
+// close stops the health check and closes the replicas, it may be called more than once.
+func (s *replicaSet) close() error {
+	if s == nil {
+		return nil
+	}
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+	s.wg.Wait()
+	var err error
+	for _, r := range s.replicas {
+		r.cache.close()
+		if e := r.db.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// OpenCluster opens a primary database and its read replicas.
+// Get and Query are executed by a replica chosen by Options.Balancer, unless ctx is pinned by ForcePrimary,
+// while Exec, the struct writes, prepared statements and transactions are executed by the primary.
+// The replicas are pinged every Options.HealthCheckInterval, a replica failing to answer doesn't receive
+// reads until it answers again and the primary serves the reads when no replica is healthy.
+func OpenCluster(driverName string, primary string, replicas ...string) (*DB, error) {
+	d, err := Open(driverName, primary)
+	if err != nil {
+		return nil, err
+	}
+	var dbs []*sql.DB
+	for _, dsn := range replicas {
+		db, err := sql.Open(driverName, dsn)
+		if err != nil {
+			for _, db := range dbs {
+				_ = db.Close()
+			}
+			_ = d.Close()
+			return nil, err
+		}
+		dbs = append(dbs, db)
+	}
+	if len(dbs) > 0 {
+		d.replicas = newReplicaSet(d.option, dbs)
+	}
+	return d, nil
+}
+
+// Replicas returns the read replicas of a cluster opened by OpenCluster.
+func (d *DB) Replicas() []*Replica {
+	if d.replicas == nil {
+		return nil
+	}
+	return d.replicas.replicas
+}
@@ -0,0 +1,147 @@
+package dbx
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// openCluster opens a cluster of sqlite databases, each one has a node table containing its name.
+func openCluster(t *testing.T, replicas ...string) *DB {
+	dir := t.TempDir()
+	dsn := func(name string) string {
+		file := "file:" + filepath.Join(dir, name+".sqlite")
+		db, err := sql.Open("sqlite3", file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+		if _, err = db.Exec("create table node(name varchar(24))"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = db.Exec("insert into node(name) values (?)", name); err != nil {
+			t.Fatal(err)
+		}
+		return file
+	}
+	var dsns []string
+	for _, name := range replicas {
+		dsns = append(dsns, dsn(name))
+	}
+	db, err := OpenCluster("sqlite3", dsn("primary"), dsns...)
+	if err != nil {
+		t.Fatalf("open cluster:%v", err)
+	}
+	db.Options().Logger = nil
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
+
+func TestOpenCluster(t *testing.T) {
+	db := openCluster(t, "replica1", "replica2")
+	if len(db.Replicas()) != 2 {
+		t.Fatalf("replicas=%d", len(db.Replicas()))
+	}
+	nodes := func(ctx context.Context, n int) []string {
+		var names []string
+		for i := 0; i < n; i++ {
+			var name string
+			if err := db.GetContext(ctx, &name, "select name from node"); err != nil {
+				t.Fatal(err)
+			}
+			names = append(names, name)
+		}
+		return names
+	}
+	got := nodes(context.Background(), 4)
+	want := []string{"replica1", "replica2", "replica1", "replica2"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("reads=%v, want %v", got, want)
+		}
+	}
+	var names []string
+	db.MustQuery(&names, "select name from node")
+	if len(names) != 1 || names[0] != "replica1" {
+		t.Errorf("query=%v", names)
+	}
+	if got := nodes(ForcePrimary(context.Background()), 1); got[0] != "primary" {
+		t.Errorf("force primary read=%v", got)
+	}
+
+	db.MustExec("update node set name = ?", "written")
+	if got := nodes(ForcePrimary(context.Background()), 1); got[0] != "written" {
+		t.Errorf("write went to %v", got)
+	}
+	err := db.Transaction(func(tx *Tx) error {
+		var name string
+		if err := tx.Get(&name, "select name from node"); err != nil {
+			return err
+		}
+		if name != "written" {
+			t.Errorf("tx read=%s", name)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenCluster_HealthCheck(t *testing.T) {
+	db := openCluster(t, "replica1", "replica2")
+	replica1 := db.Replicas()[0]
+	_ = replica1.RawDB().Close()
+	db.replicas.check(context.Background(), time.Second)
+	if replica1.Healthy() || !db.Replicas()[1].Healthy() {
+		t.Fatalf("healthy=%v,%v", replica1.Healthy(), db.Replicas()[1].Healthy())
+	}
+	for i := 0; i < 3; i++ {
+		var name string
+		db.MustGet(&name, "select name from node")
+		if name != "replica2" {
+			t.Fatalf("read from %s", name)
+		}
+	}
+
+	_ = db.Replicas()[1].RawDB().Close()
+	db.replicas.check(context.Background(), time.Second)
+	var name string
+	db.MustGet(&name, "select name from node")
+	if name != "primary" {
+		t.Errorf("read from %s without healthy replica", name)
+	}
+}
+
+func TestOpenCluster_CloseTwice(t *testing.T) {
+	db := openCluster(t, "replica1")
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	_ = db.Close()
+}
+
+func TestBalancer(t *testing.T) {
+	replicas := []*Replica{{inFlight: 3}, {inFlight: 1}, {inFlight: 2}}
+	if r := NewLeastInFlightBalancer().Pick(replicas); r != replicas[1] {
+		t.Errorf("least in flight picked %d", r.InFlight())
+	}
+	rr := NewRoundRobinBalancer()
+	for i := 0; i < 6; i++ {
+		if r := rr.Pick(replicas); r != replicas[i%3] {
+			t.Errorf("round robin pick %d=%d", i, r.InFlight())
+		}
+	}
+	picked := map[*Replica]bool{}
+	random := NewRandomBalancer()
+	for i := 0; i < 100; i++ {
+		picked[random.Pick(replicas)] = true
+	}
+	if len(picked) != 3 {
+		t.Errorf("random picked %d replicas", len(picked))
+	}
+}
@@ -0,0 +1,136 @@
+package internal
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFiles are the names of the configuration file looked up in the working directory.
+var ConfigFiles = []string{"dbx-gen.yaml", "dbx-gen.yml", "dbx-gen.json"}
+
+// Config is the content of a dbx-gen.yaml or dbx-gen.json file, like:
+//
+//	driver: postgres
+//	ddl: [migrations]
+//	types:
+//	  numeric: github.com/shopspring/decimal.Decimal
+//	columns:
+//	  orders.amount: github.com/shopspring/decimal.Decimal
+//	structs:
+//	  orders: PurchaseOrder
+//	fields:
+//	  orders.amount: Total
+//	acronyms: [URL, UUID]
+//	tags: [yaml]
+//	column_tags:
+//	  users.email: validate:"required,email"
+//
+// The Go types are qualified by the import path of their package like github.com/shopspring/decimal.Decimal,
+// which is imported by the generated code.
+type Config struct {
+	Package string   `yaml:"package" json:"package"`
+	Output  string   `yaml:"output" json:"output"`
+	Driver  string   `yaml:"driver" json:"driver"`
+	URI     string   `yaml:"uri" json:"uri"`
+	Schema  string   `yaml:"schema" json:"schema"`
+	DDL     []string `yaml:"ddl" json:"ddl"`
+	Tables  []string `yaml:"tables" json:"tables"`
+	Exclude []string `yaml:"exclude" json:"exclude"`
+	Split   bool     `yaml:"split" json:"split"`
+	// Types are the Go types of the database types, like numeric or tinyint(1), replacing the built-in ones.
+	Types map[string]string `yaml:"types" json:"types"`
+	// Columns are the Go types of the columns by table.column, they are used as is for the nullable columns.
+	Columns map[string]string `yaml:"columns" json:"columns"`
+	// Structs are the names of the structs by table, the Record suffix is appended.
+	Structs map[string]string `yaml:"structs" json:"structs"`
+	// Fields are the names of the fields by table.column or by column for every table.
+	Fields map[string]string `yaml:"fields" json:"fields"`
+	// Acronyms are the words written in upper case in the names, like URL for image_url.
+	Acronyms []string `yaml:"acronyms" json:"acronyms"`
+	// Tags are the keys of the struct tags added with the column name like json, for example yaml.
+	Tags []string `yaml:"tags" json:"tags"`
+	// ColumnTags are the struct tags added to the fields by table.column.
+	ColumnTags map[string]string `yaml:"column_tags" json:"column_tags"`
+}
+
+// ReadConfig reads the configuration file name, YAML unless its extension is .json.
+func ReadConfig(name string) (*Config, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	c := &Config{}
+	if strings.EqualFold(filepath.Ext(name), ".json") {
+		err = json.Unmarshal(b, c)
+	} else {
+		err = yaml.Unmarshal(b, c)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// FindConfig returns the first of ConfigFiles in the working directory, empty if there is none.
+func FindConfig() string {
+	for _, name := range ConfigFiles {
+		if _, err := os.Stat(name); err == nil {
+			return name
+		}
+	}
+	return ""
+}
+
+// Merge sets the Options from c, except the ones of the command line flags for which explicit returns true.
+func (c *Config) Merge(explicit func(flag string) bool) {
+	setString := func(flag string, option *string, value string) {
+		if value != "" && !explicit(flag) {
+			*option = value
+		}
+	}
+	setStrings := func(flag string, option *[]string, value []string) {
+		if len(value) > 0 && !explicit(flag) {
+			*option = value
+		}
+	}
+	setString("p", &Options.Package, c.Package)
+	setString("o", &Options.Output, c.Output)
+	setString("driver", &Options.Driver, c.Driver)
+	setString("uri", &Options.DataSourceName, c.URI)
+	setString("schema", &Options.Schema, c.Schema)
+	setStrings("ddl", &Options.DDL, c.DDL)
+	setStrings("tables", &Options.Tables, c.Tables)
+	setStrings("exclude", &Options.Exclude, c.Exclude)
+	if c.Split && !explicit("split") {
+		Options.Split = true
+	}
+	Options.Types = map[string]string{}
+	for k, v := range c.Types {
+		Options.Types[strings.ToUpper(k)] = v
+	}
+	Options.ColumnTypes = c.Columns
+	Options.StructNames = c.Structs
+	Options.FieldNames = c.Fields
+	Options.Acronyms = c.Acronyms
+	Options.Tags = c.Tags
+	Options.ColumnTags = c.ColumnTags
+}
+
+// goType splits a Go type qualified by the import path of its package, like *github.com/shopspring/decimal.Decimal,
+// into the type of the generated code, *decimal.Decimal, and the import path, github.com/shopspring/decimal.
+// The import path is empty for the predeclared types.
+func goType(qualified string) (string, string) {
+	name := strings.TrimLeft(qualified, "*[]")
+	prefix := qualified[:len(qualified)-len(name)]
+	dot := strings.LastIndex(name, ".")
+	if dot < 0 || dot < strings.LastIndex(name, "/") {
+		return qualified, ""
+	}
+	importPath := name[:dot]
+	return prefix + path.Base(importPath) + name[dot:], importPath
+}
@@ -0,0 +1,145 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/microbun/dbx/introspect"
+)
+
+func TestReadConfig(t *testing.T) {
+	dir := t.TempDir()
+	yamlFile := filepath.Join(dir, "dbx-gen.yaml")
+	err := os.WriteFile(yamlFile, []byte(`
+package: model
+driver: postgres
+ddl: [migrations]
+split: true
+types:
+  numeric: github.com/shopspring/decimal.Decimal
+columns:
+  orders.amount: "*github.com/shopspring/decimal.Decimal"
+acronyms: [URL]
+column_tags:
+  users.email: validate:"required,email"
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonFile := filepath.Join(dir, "dbx-gen.json")
+	if err = os.WriteFile(jsonFile, []byte(`{"package": "model", "tags": ["yaml"], "structs": {"orders": "PurchaseOrder"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := ReadConfig(yamlFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Package != "model" || !c.Split || !reflect.DeepEqual(c.DDL, []string{"migrations"}) ||
+		c.Columns["orders.amount"] != "*github.com/shopspring/decimal.Decimal" || c.ColumnTags["users.email"] != `validate:"required,email"` {
+		t.Errorf("yaml config=%+v", c)
+	}
+	if c, err = ReadConfig(jsonFile); err != nil || c.Structs["orders"] != "PurchaseOrder" || !reflect.DeepEqual(c.Tags, []string{"yaml"}) {
+		t.Errorf("json config=%+v err=%v", c, err)
+	}
+	if _, err = ReadConfig(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestConfig_Merge(t *testing.T) {
+	saved := Options
+	defer func() { Options = saved }()
+	Options.Package, Options.Driver, Options.Output = "module", "mysql", "module.gen.go"
+	c := &Config{Package: "model", Driver: "postgres", DDL: []string{"schema.sql"}, Types: map[string]string{"numeric": "float32"}}
+	c.Merge(func(flag string) bool {
+		return flag == "driver"
+	})
+	if Options.Package != "model" || Options.Driver != "mysql" || Options.Output != "module.gen.go" ||
+		!reflect.DeepEqual(Options.DDL, []string{"schema.sql"}) || Options.Types["NUMERIC"] != "float32" {
+		t.Errorf("options=%+v", Options)
+	}
+}
+
+func Test_goType(t *testing.T) {
+	tests := map[string][2]string{
+		"github.com/shopspring/decimal.Decimal":  {"decimal.Decimal", "github.com/shopspring/decimal"},
+		"*github.com/shopspring/decimal.Decimal": {"*decimal.Decimal", "github.com/shopspring/decimal"},
+		"[]encoding/json.RawMessage":             {"[]json.RawMessage", "encoding/json"},
+		"time.Duration":                          {"time.Duration", "time"},
+		"string":                                 {"string", ""},
+		"[]byte":                                 {"[]byte", ""},
+	}
+	for in, want := range tests {
+		if typ, importPath := goType(in); typ != want[0] || importPath != want[1] {
+			t.Errorf("goType(%s)=%s %s, want %s %s", in, typ, importPath, want[0], want[1])
+		}
+	}
+}
+
+func Test_goName(t *testing.T) {
+	saved := Options
+	defer func() { Options = saved }()
+	Options.Acronyms = []string{"url"}
+	tests := map[string]string{
+		"user_id":    "UserId",
+		"id":         "ID",
+		"image_url":  "ImageURL",
+		"url":        "URL",
+		"order2_url": "Order2URL",
+	}
+	for in, want := range tests {
+		if got := goName(in); got != want {
+			t.Errorf("goName(%s)=%s, want %s", in, got, want)
+		}
+	}
+}
+
+func Test_render_Config(t *testing.T) {
+	saved := Options
+	defer func() { Options = saved }()
+	(&Config{
+		Types:      map[string]string{"numeric": "github.com/shopspring/decimal.Decimal"},
+		Columns:    map[string]string{"orders.total": "github.com/example/money.Amount"},
+		Structs:    map[string]string{"orders": "PurchaseOrder"},
+		Fields:     map[string]string{"orders.user_id": "Buyer", "created_at": "Created"},
+		Acronyms:   []string{"URL", "ID"},
+		Tags:       []string{"yaml"},
+		ColumnTags: map[string]string{"orders.image_url": `validate:"url"`},
+	}).Merge(func(string) bool { return false })
+
+	schema := &introspect.Schema{Tables: []*introspect.Table{{
+		Name: "orders",
+		Columns: []*introspect.Column{
+			{Name: "id", Position: 1, DataType: "int8", PrimaryKey: true},
+			{Name: "user_id", Position: 2, DataType: "int8"},
+			{Name: "image_url", Position: 3, DataType: "text", Nullable: true},
+			{Name: "price", Position: 4, DataType: "numeric", Nullable: true},
+			{Name: "total", Position: 5, DataType: "numeric", Nullable: true},
+			{Name: "created_at", Position: 6, DataType: "timestamptz"},
+			{Name: "tracking_id", Position: 7, DataType: "text"},
+		},
+	}}}
+	src, err := render(Context{Tables: tablesOf(schema), Package: "module", Quote: quoteOf("postgres")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := strings.Join(strings.Fields(string(src)), " ")
+	for _, want := range []string{
+		`"github.com/example/money" "github.com/shopspring/decimal" )`,
+		"type PurchaseOrderRecord struct",
+		"Buyer int64 `",
+		"ImageURL *string `dbx:\"column:image_url\" json:\"image_url,omitempty\" yaml:\"image_url,omitempty\" validate:\"url\" `",
+		"Price *decimal.Decimal `",
+		"Total money.Amount `",
+		"Created time.Time `",
+		"TrackingID string `",
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("missing %s in:\n%s", want, src)
+		}
+	}
+}
@@ -0,0 +1,1203 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/microbun/dbx/introspect"
+	"github.com/microbun/dbx/migrate"
+)
+
+// readDDL returns the scripts of paths in order. A directory is read as the migrations of the migrate package,
+// the up scripts ordered by version, or as its .sql files ordered by name if it has no migrations.
+func readDDL(paths []string) ([]string, error) {
+	var scripts []string
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			b, err := os.ReadFile(p)
+			if err != nil {
+				return nil, err
+			}
+			scripts = append(scripts, string(b))
+			continue
+		}
+		migrations, err := migrate.LoadDir(p)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range migrations {
+			scripts = append(scripts, m.Up)
+		}
+		if len(migrations) > 0 {
+			continue
+		}
+		files, err := filepath.Glob(filepath.Join(p, "*.sql"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(files)
+		for _, f := range files {
+			if strings.HasSuffix(f, ".down.sql") {
+				continue
+			}
+			b, err := os.ReadFile(f)
+			if err != nil {
+				return nil, err
+			}
+			scripts = append(scripts, string(b))
+		}
+	}
+	return scripts, nil
+}
+
+// loadDDL returns the schema made by the scripts. The scripts of SQLite are executed into an in-memory database,
+// the ones of MySQL and PostgreSQL are parsed by parseDDL.
+func loadDDL(driver string, scripts []string) (*introspect.Schema, error) {
+	if driver != introspect.SQLite {
+		return parseDDL(driver, scripts)
+	}
+	db, err := sql.Open(introspect.SQLite, "file::memory:")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	// every connection opens a different in-memory database.
+	db.SetMaxOpenConns(1)
+	for _, script := range scripts {
+		if _, err = db.Exec(script); err != nil {
+			return nil, err
+		}
+	}
+	return introspect.Load(context.Background(), db, introspect.SQLite, "")
+}
+
+// parseDDL applies the CREATE TABLE, CREATE INDEX, ALTER TABLE, DROP and COMMENT ON statements of the scripts
+// written for driver, MySQL or PostgreSQL, the other statements are ignored.
+// The DataType of the columns is the one reported by the database, like int for MySQL and int4 for PostgreSQL,
+// so that the generated code is the same as the one generated from the database.
+func parseDDL(driver string, scripts []string) (*introspect.Schema, error) {
+	s := &ddlSchema{driver: driver, schema: &introspect.Schema{}}
+	for _, script := range scripts {
+		tokens, err := tokenize(script, driver == introspect.MySQL)
+		if err != nil {
+			return nil, err
+		}
+		start := 0
+		for i := 0; i <= len(tokens); i++ {
+			if i < len(tokens) && !tokens[i].is(";") {
+				continue
+			}
+			if i > start {
+				p := &ddlParser{tokens: tokens[start:i]}
+				if err = s.apply(p); err != nil {
+					return nil, fmt.Errorf("ddl: %v in %s", err, p.text(0, len(p.tokens)))
+				}
+			}
+			start = i + 1
+		}
+	}
+	return s.schema, nil
+}
+
+type tokenKind int
+
+const (
+	// word is a keyword, an unquoted identifier or a number.
+	word tokenKind = iota
+	// quotedIdent is an identifier quoted by ` or ".
+	quotedIdent
+	// str is a string literal, its text is unquoted.
+	str
+	punct
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// is reports whether t is the word or the punctuation s, regardless of the case.
+func (t token) is(s string) bool {
+	return (t.kind == word || t.kind == punct) && strings.EqualFold(t.text, s)
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case quotedIdent:
+		return `"` + t.text + `"`
+	case str:
+		return "'" + strings.Replace(t.text, "'", "''", -1) + "'"
+	}
+	return t.text
+}
+
+// mysqlEscape decodes the character following a backslash in a MySQL string,
+// \% and \_ keep their backslash and any other character stands for itself.
+func mysqlEscape(c byte) string {
+	switch c {
+	case '0':
+		return "\x00"
+	case 'b':
+		return "\b"
+	case 'n':
+		return "\n"
+	case 'r':
+		return "\r"
+	case 't':
+		return "\t"
+	case 'Z':
+		return "\x1a"
+	case '%', '_':
+		return "\\" + string(c)
+	}
+	return string(c)
+}
+
+// tokenize splits a script into tokens, the comments are dropped.
+// The # comments and the backslash escapes in strings are MySQL only:
+// # is an operator of PostgreSQL, whose backslashes are literal in the standard strings.
+func tokenize(script string, mysql bool) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(script); {
+		c := script[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '-' && strings.HasPrefix(script[i:], "--"), c == '#' && mysql:
+			for i < len(script) && script[i] != '\n' {
+				i++
+			}
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment")
+			}
+			i += end + 4
+		case c == '\'' || c == '"' || c == '`':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(script); j++ {
+				if script[j] == '\\' && c == '\'' && mysql && j+1 < len(script) {
+					j++
+					b.WriteString(mysqlEscape(script[j]))
+					continue
+				} else if script[j] == c {
+					// a doubled quote is an escaped quote.
+					if j+1 < len(script) && script[j+1] == c {
+						j++
+					} else {
+						break
+					}
+				}
+				b.WriteByte(script[j])
+			}
+			if j >= len(script) {
+				return nil, fmt.Errorf("unterminated quote %c", c)
+			}
+			kind := quotedIdent
+			if c == '\'' {
+				kind = str
+			}
+			tokens = append(tokens, token{kind: kind, text: b.String()})
+			i = j + 1
+		case c == '$' && dollarQuote.MatchString(script[i:]):
+			// the dollar-quoted strings of PostgreSQL, like the body of a function.
+			tag := dollarQuote.FindString(script[i:])
+			end := strings.Index(script[i+len(tag):], tag)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quote %s", tag)
+			}
+			tokens = append(tokens, token{kind: str, text: script[i+len(tag) : i+len(tag)+end]})
+			i += len(tag) + end + len(tag)
+		case isWordByte(c):
+			j := i
+			for j < len(script) && isWordByte(script[j]) {
+				j++
+			}
+			tokens = append(tokens, token{kind: word, text: script[i:j]})
+			i = j
+		case c == ':' && strings.HasPrefix(script[i:], "::"):
+			tokens = append(tokens, token{kind: punct, text: "::"})
+			i += 2
+		default:
+			tokens = append(tokens, token{kind: punct, text: string(c)})
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+var dollarQuote = regexp.MustCompile(`^\$[A-Za-z_]*\$`)
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// ddlParser reads the tokens of a statement.
+type ddlParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *ddlParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *ddlParser) peek() token {
+	if p.done() {
+		return token{kind: punct}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *ddlParser) next() token {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+// is reports whether the next tokens are words.
+func (p *ddlParser) is(words ...string) bool {
+	for i, w := range words {
+		if p.pos+i >= len(p.tokens) || !p.tokens[p.pos+i].is(w) {
+			return false
+		}
+	}
+	return true
+}
+
+// accept consumes words if they are the next tokens.
+func (p *ddlParser) accept(words ...string) bool {
+	if p.is(words...) {
+		p.pos += len(words)
+		return true
+	}
+	return false
+}
+
+func (p *ddlParser) expect(words ...string) error {
+	if !p.accept(words...) {
+		return fmt.Errorf("expected %s near %s", strings.Join(words, " "), p.peek())
+	}
+	return nil
+}
+
+// ident reads an identifier, the schema of a qualified name is dropped.
+func (p *ddlParser) ident() (string, error) {
+	t := p.next()
+	if t.kind != word && t.kind != quotedIdent {
+		return "", fmt.Errorf("expected an identifier near %s", t)
+	}
+	if p.accept(".") {
+		return p.ident()
+	}
+	return t.text, nil
+}
+
+// identList reads a parenthesized list of columns, the lengths, the orders and the expressions are dropped.
+func (p *ddlParser) identList() ([]string, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var names []string
+	for {
+		t := p.peek()
+		if (t.kind == word || t.kind == quotedIdent) && !p.isCall() {
+			names = append(names, t.text)
+		}
+		p.skipUntil(",", ")")
+		if p.accept(")") {
+			return names, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// isCall reports whether the next tokens are a function call, like lower(name) in an index,
+// rather than a column followed by a prefix length like name(10).
+func (p *ddlParser) isCall() bool {
+	if p.pos+1 >= len(p.tokens) || !p.tokens[p.pos+1].is("(") {
+		return false
+	}
+	if p.pos+3 < len(p.tokens) && p.tokens[p.pos+3].is(")") {
+		n := p.tokens[p.pos+2]
+		return n.kind != word || n.text == "" || n.text[0] < '0' || n.text[0] > '9'
+	}
+	return true
+}
+
+// skipUntil consumes the tokens until one of stops outside of parentheses, which is not consumed.
+func (p *ddlParser) skipUntil(stops ...string) {
+	depth := 0
+	for !p.done() {
+		t := p.peek()
+		if depth == 0 {
+			for _, s := range stops {
+				if t.is(s) {
+					return
+				}
+			}
+		}
+		if t.is("(") {
+			depth++
+		} else if t.is(")") {
+			depth--
+		}
+		p.pos++
+	}
+}
+
+// text returns the tokens from start to end as SQL.
+func (p *ddlParser) text(start int, end int) string {
+	var b strings.Builder
+	for i := start; i < end && i < len(p.tokens); i++ {
+		t := p.tokens[i]
+		if i > start && t.kind != punct && p.tokens[i-1].kind != punct {
+			b.WriteByte(' ')
+		}
+		b.WriteString(t.String())
+	}
+	return b.String()
+}
+
+// expression reads a DEFAULT expression: a literal, a name, a function call or a parenthesized expression,
+// followed by PostgreSQL casts.
+func (p *ddlParser) expression() string {
+	start := p.pos
+	if p.accept("-") || p.accept("+") {
+		p.next()
+	} else if p.is("(") {
+		p.skipParens()
+	} else {
+		p.next()
+		if p.is("(") {
+			p.skipParens()
+		}
+	}
+	for p.accept("::") {
+		p.next()
+		if p.is("(") {
+			p.skipParens()
+		}
+	}
+	return p.text(start, p.pos)
+}
+
+// skipParens consumes a parenthesized group.
+func (p *ddlParser) skipParens() {
+	depth := 0
+	for !p.done() {
+		t := p.next()
+		if t.is("(") {
+			depth++
+		} else if t.is(")") {
+			if depth--; depth == 0 {
+				return
+			}
+		}
+	}
+}
+
+// ddlSchema is the schema being built by the statements.
+type ddlSchema struct {
+	driver string
+	schema *introspect.Schema
+}
+
+func (s *ddlSchema) postgres() bool {
+	return s.driver == introspect.Postgres
+}
+
+func (s *ddlSchema) table(name string) (*introspect.Table, error) {
+	if t := s.schema.Table(name); t != nil {
+		return t, nil
+	}
+	return nil, fmt.Errorf("unknown table %s", name)
+}
+
+func (s *ddlSchema) apply(p *ddlParser) error {
+	switch {
+	case p.accept("create"):
+		p.accept("or", "replace")
+		p.accept("temporary")
+		p.accept("temp")
+		p.accept("unlogged")
+		switch {
+		case p.accept("table"):
+			return s.createTable(p)
+		case p.is("unique"), p.is("index"):
+			return s.createIndex(p)
+		case p.accept("view"):
+			return s.createView(p)
+		}
+	case p.accept("alter", "table"):
+		return s.alterTable(p)
+	case p.accept("drop"):
+		return s.drop(p)
+	case p.accept("comment", "on"):
+		return s.comment(p)
+	}
+	return nil
+}
+
+func (s *ddlSchema) createTable(p *ddlParser) error {
+	ifNotExists := p.accept("if", "not", "exists")
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	if s.schema.Table(name) != nil {
+		if ifNotExists {
+			return nil
+		}
+		return fmt.Errorf("table %s already exists", name)
+	}
+	t := &introspect.Table{Name: name}
+	if err = p.expect("("); err != nil {
+		return err
+	}
+	for {
+		if err = s.definition(p, t); err != nil {
+			return err
+		}
+		if p.accept(")") {
+			break
+		}
+		if err = p.expect(","); err != nil {
+			return err
+		}
+	}
+	// the table options of MySQL.
+	for !p.done() {
+		if p.accept("comment") {
+			p.accept("=")
+			t.Comment = p.next().text
+			continue
+		}
+		p.next()
+	}
+	s.schema.Tables = append(s.schema.Tables, t)
+	return nil
+}
+
+// definition reads a column or a constraint of a table.
+func (s *ddlSchema) definition(p *ddlParser, t *introspect.Table) error {
+	if p.peek().kind == word {
+		for _, w := range []string{"constraint", "primary", "unique", "key", "index", "foreign", "check", "fulltext", "spatial", "exclude"} {
+			if p.is(w) {
+				return s.constraint(p, t)
+			}
+		}
+	}
+	c, err := s.column(p, t)
+	if err != nil {
+		return err
+	}
+	if t.Column(c.Name) != nil {
+		return fmt.Errorf("duplicate column %s.%s", t.Name, c.Name)
+	}
+	c.Position = len(t.Columns) + 1
+	t.Columns = append(t.Columns, c)
+	if c.PrimaryKey {
+		setPrimaryKey(t, []string{c.Name})
+	}
+	return nil
+}
+
+// column reads a column definition, the UNIQUE and REFERENCES constraints of the column are added to t.
+func (s *ddlSchema) column(p *ddlParser, t *introspect.Table) (*introspect.Column, error) {
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	c := &introspect.Column{Name: name, Nullable: true}
+	if err = s.columnType(p, c); err != nil {
+		return nil, err
+	}
+	for !p.done() && !p.is(",") && !p.is(")") {
+		switch {
+		case p.accept("not", "null"):
+			c.Nullable = false
+		case p.accept("null"):
+			c.Nullable = true
+		case p.accept("default"):
+			c.Default = nil
+			if def := p.expression(); !strings.EqualFold(def, "null") {
+				c.Default = &def
+			}
+		case p.accept("auto_increment"), p.accept("autoincrement"):
+			c.AutoIncrement = true
+		case p.accept("primary", "key"):
+			c.PrimaryKey = true
+			c.Nullable = false
+		case p.accept("unique"):
+			p.accept("key")
+			t.Indexes = append(t.Indexes, &introspect.Index{Name: s.uniqueName(t, name), Unique: true, Columns: []string{name}})
+		case p.accept("comment"):
+			c.Comment = p.next().text
+		case p.accept("references"):
+			fk, err := s.references(p, []string{name})
+			if err != nil {
+				return nil, err
+			}
+			t.ForeignKeys = append(t.ForeignKeys, fk)
+		case p.accept("generated"):
+			p.skipUntil("as")
+			p.accept("as")
+			if p.accept("identity") {
+				c.AutoIncrement = true
+			}
+			if p.is("(") {
+				p.skipParens()
+			}
+		case p.accept("on", "update"):
+			p.expression()
+		case p.is("("):
+			p.skipParens()
+		default:
+			// COLLATE, CHARACTER SET, CHECK, CONSTRAINT, the storage options...
+			p.next()
+		}
+	}
+	return c, nil
+}
+
+// columnType reads the type of c and sets its DataType and its ColumnType.
+func (s *ddlSchema) columnType(p *ddlParser, c *introspect.Column) error {
+	t := p.next()
+	if t.kind != word {
+		return fmt.Errorf("expected the type of %s near %s", c.Name, t)
+	}
+	name := strings.ToLower(t.text)
+	switch {
+	case name == "double" && p.accept("precision"):
+		name = "double precision"
+	case (name == "character" || name == "char" || name == "national") && p.accept("varying"):
+		name = "varchar"
+	}
+	args := ""
+	if p.is("(") {
+		start := p.pos
+		p.skipParens()
+		args = strings.ToLower(p.text(start, p.pos))
+	}
+	if p.accept("with", "time", "zone") {
+		name += " with time zone"
+	} else if p.accept("without", "time", "zone") {
+		name += " without time zone"
+	}
+	var modifiers []string
+	for _, m := range []string{"unsigned", "signed", "zerofill"} {
+		if p.accept(m) {
+			modifiers = append(modifiers, m)
+		}
+	}
+	array := false
+	for p.accept("[") {
+		p.skipUntil("]")
+		p.accept("]")
+		array = true
+	}
+	if p.accept("array") {
+		array = true
+	}
+	if s.postgres() {
+		var serial bool
+		c.DataType, serial = postgresType(name, args)
+		c.AutoIncrement = c.AutoIncrement || serial
+		if array {
+			c.DataType += "[]"
+		}
+		c.ColumnType = c.DataType
+		if args != "" {
+			c.ColumnType = strings.TrimSuffix(c.DataType, "[]") + args
+			if array {
+				c.ColumnType += "[]"
+			}
+		}
+		return nil
+	}
+	c.DataType, args = mysqlType(name, args)
+	c.ColumnType = strings.Join(append([]string{c.DataType + args}, modifiers...), " ")
+	return nil
+}
+
+// mysqlType returns the name of the type of information_schema.columns.data_type for the declared type.
+func mysqlType(name string, args string) (string, string) {
+	switch name {
+	case "integer":
+		return "int", args
+	case "bool", "boolean":
+		return "tinyint", "(1)"
+	case "dec", "numeric", "fixed":
+		return "decimal", args
+	case "real", "double precision":
+		return "double", args
+	case "character", "nchar":
+		return "char", args
+	case "nvarchar":
+		return "varchar", args
+	}
+	return name, args
+}
+
+// postgresType returns the udt_name of the declared type and whether the type is a serial.
+func postgresType(name string, args string) (string, bool) {
+	switch name {
+	case "int", "integer", "int4":
+		return "int4", false
+	case "bigint", "int8":
+		return "int8", false
+	case "smallint", "int2":
+		return "int2", false
+	case "serial", "serial4":
+		return "int4", true
+	case "bigserial", "serial8":
+		return "int8", true
+	case "smallserial", "serial2":
+		return "int2", true
+	case "real", "float4":
+		return "float4", false
+	case "double precision", "float8":
+		return "float8", false
+	case "float":
+		return "float8", false
+	case "boolean", "bool":
+		return "bool", false
+	case "character", "char", "bpchar":
+		return "bpchar", false
+	case "decimal", "numeric":
+		return "numeric", false
+	case "timestamp with time zone", "timestamptz":
+		return "timestamptz", false
+	case "timestamp without time zone":
+		return "timestamp", false
+	case "time with time zone", "timetz":
+		return "timetz", false
+	case "time without time zone":
+		return "time", false
+	}
+	return name, false
+}
+
+// uniqueName returns the name given by the database to the UNIQUE constraint of column.
+func (s *ddlSchema) uniqueName(t *introspect.Table, column string) string {
+	if s.postgres() {
+		return t.Name + "_" + column + "_key"
+	}
+	return column
+}
+
+// constraint reads a constraint of a table, in a CREATE TABLE or after ALTER TABLE ADD.
+func (s *ddlSchema) constraint(p *ddlParser, t *introspect.Table) error {
+	name := ""
+	if p.accept("constraint") {
+		if !p.is("primary") && !p.is("unique") && !p.is("foreign") && !p.is("check") {
+			var err error
+			if name, err = p.ident(); err != nil {
+				return err
+			}
+		}
+	}
+	switch {
+	case p.accept("primary", "key"):
+		p.skipUntil("(")
+		columns, err := p.identList()
+		if err != nil {
+			return err
+		}
+		setPrimaryKey(t, columns)
+	case p.is("unique"), p.is("key"), p.is("index"), p.is("fulltext"), p.is("spatial"):
+		unique := p.accept("unique")
+		p.accept("fulltext")
+		p.accept("spatial")
+		if !p.accept("key") {
+			p.accept("index")
+		}
+		if !p.is("(") && !p.is("using") {
+			var err error
+			if name, err = p.ident(); err != nil {
+				return err
+			}
+		}
+		p.skipUntil("(")
+		columns, err := p.identList()
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			name = columns[0]
+			if s.postgres() {
+				name = t.Name + "_" + strings.Join(columns, "_") + "_key"
+			}
+		}
+		t.Indexes = append(t.Indexes, &introspect.Index{Name: name, Unique: unique, Columns: columns})
+	case p.accept("foreign", "key"):
+		if !p.is("(") {
+			if _, err := p.ident(); err != nil {
+				return err
+			}
+		}
+		columns, err := p.identList()
+		if err != nil {
+			return err
+		}
+		if err = p.expect("references"); err != nil {
+			return err
+		}
+		fk, err := s.references(p, columns)
+		if err != nil {
+			return err
+		}
+		fk.Name = name
+		t.ForeignKeys = append(t.ForeignKeys, fk)
+	}
+	// CHECK and EXCLUDE are not reported.
+	p.skipUntil(",", ")")
+	return nil
+}
+
+// references reads the table and the columns after REFERENCES and the referential actions.
+func (s *ddlSchema) references(p *ddlParser, columns []string) (*introspect.ForeignKey, error) {
+	table, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	fk := &introspect.ForeignKey{Columns: columns, RefTable: table, OnUpdate: "NO ACTION", OnDelete: "NO ACTION"}
+	if p.is("(") {
+		if fk.RefColumns, err = p.identList(); err != nil {
+			return nil, err
+		}
+	} else if ref := s.schema.Table(table); ref != nil {
+		fk.RefColumns = ref.PrimaryKey
+	}
+	for {
+		p.accept("match", "full")
+		p.accept("match", "simple")
+		p.accept("match", "partial")
+		var action *string
+		if p.accept("on", "delete") {
+			action = &fk.OnDelete
+		} else if p.accept("on", "update") {
+			action = &fk.OnUpdate
+		} else {
+			return fk, nil
+		}
+		switch {
+		case p.accept("set", "null"):
+			*action = "SET NULL"
+		case p.accept("set", "default"):
+			*action = "SET DEFAULT"
+		case p.accept("no", "action"):
+			*action = "NO ACTION"
+		default:
+			*action = strings.ToUpper(p.next().text)
+		}
+	}
+}
+
+func setPrimaryKey(t *introspect.Table, columns []string) {
+	t.PrimaryKey = columns
+	for _, c := range t.Columns {
+		c.PrimaryKey = false
+	}
+	for _, name := range columns {
+		if c := t.Column(name); c != nil {
+			c.PrimaryKey = true
+			c.Nullable = false
+		}
+	}
+}
+
+func (s *ddlSchema) createIndex(p *ddlParser) error {
+	unique := p.accept("unique")
+	if err := p.expect("index"); err != nil {
+		return err
+	}
+	p.accept("concurrently")
+	p.accept("if", "not", "exists")
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	if err = p.expect("on"); err != nil {
+		return err
+	}
+	p.accept("only")
+	table, err := p.ident()
+	if err != nil {
+		return err
+	}
+	t, err := s.table(table)
+	if err != nil {
+		return err
+	}
+	p.skipUntil("(")
+	columns, err := p.identList()
+	if err != nil {
+		return err
+	}
+	t.Indexes = append(t.Indexes, &introspect.Index{Name: name, Unique: unique, Columns: columns})
+	return nil
+}
+
+func (s *ddlSchema) createView(p *ddlParser) error {
+	p.accept("if", "not", "exists")
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	p.skipUntil("as")
+	p.accept("as")
+	s.dropView(name)
+	s.schema.Views = append(s.schema.Views, &introspect.View{Name: name, Definition: p.text(p.pos, len(p.tokens))})
+	return nil
+}
+
+func (s *ddlSchema) alterTable(p *ddlParser) error {
+	p.accept("if", "exists")
+	p.accept("only")
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	t, err := s.table(name)
+	if err != nil {
+		return err
+	}
+	for !p.done() {
+		if err = s.alter(p, t); err != nil {
+			return err
+		}
+		p.skipUntil(",")
+		p.accept(",")
+	}
+	return nil
+}
+
+// alter reads an action of ALTER TABLE.
+func (s *ddlSchema) alter(p *ddlParser, t *introspect.Table) error {
+	switch {
+	case p.accept("add"):
+		if p.is("constraint") || p.is("primary") || p.is("unique") || p.is("key") || p.is("index") || p.is("foreign") ||
+			p.is("check") || p.is("fulltext") || p.is("spatial") {
+			return s.constraint(p, t)
+		}
+		p.accept("column")
+		p.accept("if", "not", "exists")
+		return s.definition(p, t)
+	case p.accept("drop"):
+		switch {
+		case p.accept("primary", "key"):
+			setPrimaryKey(t, nil)
+		case p.accept("index"), p.accept("key"), p.accept("foreign", "key"), p.accept("constraint"):
+			p.accept("if", "exists")
+			name, err := p.ident()
+			if err != nil {
+				return err
+			}
+			dropConstraint(t, name)
+		default:
+			p.accept("column")
+			p.accept("if", "exists")
+			name, err := p.ident()
+			if err != nil {
+				return err
+			}
+			dropColumn(t, name)
+		}
+	case p.accept("modify"):
+		p.accept("column")
+		return s.replaceColumn(p, t, "")
+	case p.accept("change"):
+		p.accept("column")
+		old, err := p.ident()
+		if err != nil {
+			return err
+		}
+		return s.replaceColumn(p, t, old)
+	case p.accept("rename", "column"):
+		old, err := p.ident()
+		if err != nil {
+			return err
+		}
+		if err = p.expect("to"); err != nil {
+			return err
+		}
+		name, err := p.ident()
+		if err != nil {
+			return err
+		}
+		renameColumn(t, old, name)
+	case p.accept("rename", "index"), p.accept("rename", "key"):
+		old, err := p.ident()
+		if err != nil {
+			return err
+		}
+		if err = p.expect("to"); err != nil {
+			return err
+		}
+		name, err := p.ident()
+		if err != nil {
+			return err
+		}
+		for _, idx := range t.Indexes {
+			if idx.Name == old {
+				idx.Name = name
+			}
+		}
+	case p.accept("rename"):
+		table := p.accept("to") || p.accept("as")
+		name, err := p.ident()
+		if err != nil {
+			return err
+		}
+		// RENAME c TO d renames a column for PostgreSQL.
+		if !table && p.accept("to") {
+			to, err := p.ident()
+			if err != nil {
+				return err
+			}
+			renameColumn(t, name, to)
+			return nil
+		}
+		t.Name = name
+	case p.accept("alter"):
+		p.accept("column")
+		name, err := p.ident()
+		if err != nil {
+			return err
+		}
+		c := t.Column(name)
+		if c == nil {
+			return fmt.Errorf("unknown column %s.%s", t.Name, name)
+		}
+		switch {
+		case p.accept("set", "not", "null"):
+			c.Nullable = false
+		case p.accept("drop", "not", "null"):
+			c.Nullable = true
+		case p.accept("set", "default"):
+			def := p.expression()
+			c.Default = &def
+		case p.accept("drop", "default"):
+			c.Default = nil
+		case p.accept("add", "generated"):
+			c.AutoIncrement = true
+		case p.accept("drop", "identity"):
+			c.AutoIncrement = false
+		case p.accept("set", "data", "type"), p.accept("type"):
+			return s.columnType(p, c)
+		}
+	}
+	return nil
+}
+
+// replaceColumn replaces the column old, or the column of the same name if old is empty, by the definition of MODIFY or CHANGE.
+func (s *ddlSchema) replaceColumn(p *ddlParser, t *introspect.Table, old string) error {
+	c, err := s.column(p, t)
+	if err != nil {
+		return err
+	}
+	if old == "" {
+		old = c.Name
+	}
+	for i, existing := range t.Columns {
+		if strings.EqualFold(existing.Name, old) {
+			c.Position = existing.Position
+			c.PrimaryKey = c.PrimaryKey || existing.PrimaryKey
+			if c.PrimaryKey {
+				c.Nullable = false
+			}
+			t.Columns[i] = c
+			renameColumn(t, old, c.Name)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown column %s.%s", t.Name, old)
+}
+
+func renameColumn(t *introspect.Table, old string, name string) {
+	rename := func(columns []string) {
+		for i, c := range columns {
+			if strings.EqualFold(c, old) {
+				columns[i] = name
+			}
+		}
+	}
+	if c := t.Column(old); c != nil {
+		c.Name = name
+	}
+	rename(t.PrimaryKey)
+	for _, idx := range t.Indexes {
+		rename(idx.Columns)
+	}
+	for _, fk := range t.ForeignKeys {
+		rename(fk.Columns)
+	}
+}
+
+func dropColumn(t *introspect.Table, name string) {
+	var columns []*introspect.Column
+	for _, c := range t.Columns {
+		if !strings.EqualFold(c.Name, name) {
+			c.Position = len(columns) + 1
+			columns = append(columns, c)
+		}
+	}
+	t.Columns = columns
+	var indexes []*introspect.Index
+	for _, idx := range t.Indexes {
+		if !contains(idx.Columns, name) {
+			indexes = append(indexes, idx)
+		}
+	}
+	t.Indexes = indexes
+	var foreignKeys []*introspect.ForeignKey
+	for _, fk := range t.ForeignKeys {
+		if !contains(fk.Columns, name) {
+			foreignKeys = append(foreignKeys, fk)
+		}
+	}
+	t.ForeignKeys = foreignKeys
+	if contains(t.PrimaryKey, name) {
+		setPrimaryKey(t, nil)
+	}
+}
+
+// dropConstraint drops the index or the foreign key name, or the primary key of PostgreSQL named <table>_pkey.
+func dropConstraint(t *introspect.Table, name string) {
+	if name == t.Name+"_pkey" {
+		setPrimaryKey(t, nil)
+	}
+	var indexes []*introspect.Index
+	for _, idx := range t.Indexes {
+		if idx.Name != name {
+			indexes = append(indexes, idx)
+		}
+	}
+	t.Indexes = indexes
+	var foreignKeys []*introspect.ForeignKey
+	for _, fk := range t.ForeignKeys {
+		if fk.Name != name {
+			foreignKeys = append(foreignKeys, fk)
+		}
+	}
+	t.ForeignKeys = foreignKeys
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *ddlSchema) drop(p *ddlParser) error {
+	switch {
+	case p.accept("table"):
+		p.accept("if", "exists")
+		for {
+			name, err := p.ident()
+			if err != nil {
+				return err
+			}
+			var tables []*introspect.Table
+			for _, t := range s.schema.Tables {
+				if t.Name != name {
+					tables = append(tables, t)
+				}
+			}
+			s.schema.Tables = tables
+			if !p.accept(",") {
+				return nil
+			}
+		}
+	case p.accept("index"):
+		p.accept("concurrently")
+		p.accept("if", "exists")
+		name, err := p.ident()
+		if err != nil {
+			return err
+		}
+		for _, t := range s.schema.Tables {
+			dropConstraint(t, name)
+		}
+	case p.accept("view"):
+		p.accept("if", "exists")
+		name, err := p.ident()
+		if err != nil {
+			return err
+		}
+		s.dropView(name)
+	}
+	return nil
+}
+
+func (s *ddlSchema) dropView(name string) {
+	var views []*introspect.View
+	for _, v := range s.schema.Views {
+		if v.Name != name {
+			views = append(views, v)
+		}
+	}
+	s.schema.Views = views
+}
+
+// comment reads COMMENT ON TABLE and COMMENT ON COLUMN of PostgreSQL.
+func (s *ddlSchema) comment(p *ddlParser) error {
+	switch {
+	case p.accept("table"):
+		name, err := p.ident()
+		if err != nil {
+			return err
+		}
+		t, err := s.table(name)
+		if err != nil {
+			return err
+		}
+		if err = p.expect("is"); err != nil {
+			return err
+		}
+		t.Comment = p.next().text
+	case p.accept("column"):
+		var parts []string
+		for {
+			t := p.next()
+			parts = append(parts, t.text)
+			if !p.accept(".") {
+				break
+			}
+		}
+		if len(parts) < 2 {
+			return fmt.Errorf("expected table.column")
+		}
+		t, err := s.table(parts[len(parts)-2])
+		if err != nil {
+			return err
+		}
+		c := t.Column(parts[len(parts)-1])
+		if c == nil {
+			return fmt.Errorf("unknown column %s.%s", t.Name, parts[len(parts)-1])
+		}
+		if err = p.expect("is"); err != nil {
+			return err
+		}
+		c.Comment = p.next().text
+	}
+	return nil
+}
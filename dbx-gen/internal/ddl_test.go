@@ -0,0 +1,242 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/microbun/dbx/introspect"
+)
+
+func Test_parseDDL_MySQL(t *testing.T) {
+	script := "-- users and their orders\n" +
+		"CREATE TABLE IF NOT EXISTS `users` (\n" +
+		"  `id` int(11) unsigned NOT NULL AUTO_INCREMENT,\n" +
+		"  `email` varchar(128) NOT NULL COMMENT 'login',\n" +
+		"  `active` boolean DEFAULT '1',\n" +
+		"  `name` varchar(64) DEFAULT NULL,\n" +
+		"  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,\n" +
+		"  PRIMARY KEY (`id`),\n" +
+		"  UNIQUE KEY `uk_email` (`email`),\n" +
+		"  KEY `idx_name` (`name`(10))\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='the users';\n" +
+		"CREATE TABLE orders (id bigint primary key auto_increment, user_id int unsigned not null, amount decimal(10,2),\n" +
+		"  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE);\n" +
+		"/* the second version */\n" +
+		"ALTER TABLE users ADD COLUMN level smallint NOT NULL DEFAULT 0 AFTER name, DROP COLUMN active, MODIFY name varchar(32);\n" +
+		"ALTER TABLE orders CHANGE amount total decimal(12,2) NOT NULL;\n" +
+		"CREATE INDEX idx_orders_total ON orders (total);\n" +
+		"INSERT INTO users (email) VALUES ('a;b');\n"
+	s, err := parseDDL("mysql", []string{script})
+	if err != nil {
+		t.Fatal(err)
+	}
+	users, orders := s.Table("users"), s.Table("orders")
+	if users == nil || orders == nil || len(s.Tables) != 2 {
+		t.Fatalf("tables=%+v", s.Tables)
+	}
+	if users.Comment != "the users" {
+		t.Errorf("comment=%q", users.Comment)
+	}
+	var columns []string
+	for _, c := range users.Columns {
+		columns = append(columns, c.Name+" "+c.ColumnType)
+	}
+	want := []string{"id int(11) unsigned", "email varchar(128)", "name varchar(32)", "created_at datetime", "level smallint"}
+	if !reflect.DeepEqual(columns, want) {
+		t.Errorf("columns=%q", columns)
+	}
+	id := users.Column("id")
+	if !id.PrimaryKey || !id.AutoIncrement || id.Nullable || id.DataType != "int" || id.Position != 1 {
+		t.Errorf("id=%+v", id)
+	}
+	if c := users.Column("email"); c.Nullable || c.Comment != "login" {
+		t.Errorf("email=%+v", c)
+	}
+	if c := users.Column("name"); !c.Nullable || c.Default != nil {
+		t.Errorf("name=%+v", c)
+	}
+	if c := users.Column("level"); c.Position != 5 || c.Default == nil || *c.Default != "0" {
+		t.Errorf("level=%+v", c)
+	}
+	wantIndexes := []*introspect.Index{
+		{Name: "uk_email", Unique: true, Columns: []string{"email"}},
+		{Name: "idx_name", Columns: []string{"name"}},
+	}
+	if !reflect.DeepEqual(users.Indexes, wantIndexes) {
+		t.Errorf("indexes=%+v", users.Indexes)
+	}
+	if c := orders.Column("total"); c == nil || c.Nullable || c.ColumnType != "decimal(12,2)" || c.Position != 3 {
+		t.Errorf("total=%+v", c)
+	}
+	wantFK := []*introspect.ForeignKey{{Name: "fk_user", Columns: []string{"user_id"}, RefTable: "users",
+		RefColumns: []string{"id"}, OnUpdate: "NO ACTION", OnDelete: "CASCADE"}}
+	if !reflect.DeepEqual(orders.ForeignKeys, wantFK) {
+		t.Errorf("foreign keys=%+v", orders.ForeignKeys[0])
+	}
+	if len(orders.Indexes) != 1 || orders.Indexes[0].Columns[0] != "total" {
+		t.Errorf("orders indexes=%+v", orders.Indexes)
+	}
+}
+
+func Test_tokenize_MySQLEscapes(t *testing.T) {
+	tokens, err := tokenize(`'a\nb\tc\0d\\e\'f\"g\%h\xi'`, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 1 || tokens[0].text != "a\nb\tc\x00d\\e'f\"g\\%hxi" {
+		t.Fatalf("tokens=%q", tokens)
+	}
+	tokens, err = tokenize(`'a\nb'`, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 1 || tokens[0].text != `a\nb` {
+		t.Fatalf("tokens=%q", tokens)
+	}
+}
+
+func Test_parseDDL_Postgres(t *testing.T) {
+	script := `
+CREATE TABLE customers (
+	id bigint GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+	uid uuid NOT NULL UNIQUE,
+	name character varying(64),
+	home text DEFAULT 'C:\'
+);
+CREATE TABLE "orders" (
+	"id" serial PRIMARY KEY,
+	customer_id bigint NOT NULL REFERENCES customers ON DELETE SET NULL,
+	tags text[],
+	payload jsonb DEFAULT '{}'::jsonb,
+	amount numeric(10, 2),
+	paid boolean NOT NULL DEFAULT false,
+	data bytea,
+	created_at timestamp(3) with time zone NOT NULL DEFAULT now()
+);
+CREATE FUNCTION touch() RETURNS trigger AS $$
+BEGIN
+	ALTER TABLE ignored ADD COLUMN x int;
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+ALTER TABLE orders RENAME customer_id TO buyer_id;
+ALTER TABLE orders ALTER COLUMN id TYPE bigint;
+COMMENT ON TABLE orders IS 'customer orders';
+COMMENT ON COLUMN public.orders.amount IS 'total';
+`
+	s, err := parseDDL("postgres", []string{script})
+	if err != nil {
+		t.Fatal(err)
+	}
+	customers, orders := s.Table("customers"), s.Table("orders")
+	if c := customers.Column("id"); !c.AutoIncrement || !c.PrimaryKey || c.DataType != "int8" {
+		t.Errorf("identity=%+v", c)
+	}
+	if c := customers.Column("name"); c.DataType != "varchar" || c.ColumnType != "varchar(64)" {
+		t.Errorf("name=%+v", c)
+	}
+	if c := customers.Column("home"); c == nil || c.Default == nil || *c.Default != `'C:\'` {
+		t.Errorf("home=%+v", c)
+	}
+	if len(customers.Indexes) != 1 || customers.Indexes[0].Name != "customers_uid_key" {
+		t.Errorf("indexes=%+v", customers.Indexes)
+	}
+	types := map[string]string{
+		"id": "int8", "buyer_id": "int8", "tags": "text[]", "payload": "jsonb", "amount": "numeric",
+		"paid": "bool", "data": "bytea", "created_at": "timestamptz",
+	}
+	for name, want := range types {
+		if c := orders.Column(name); c == nil || c.DataType != want {
+			t.Errorf("%s=%+v, want %s", name, c, want)
+		}
+	}
+	if c := orders.Column("id"); !c.AutoIncrement {
+		t.Errorf("serial=%+v", c)
+	}
+	if c := orders.Column("payload"); *c.Default != "'{}'::jsonb" {
+		t.Errorf("payload default=%s", *c.Default)
+	}
+	if orders.Comment != "customer orders" || orders.Column("amount").Comment != "total" {
+		t.Errorf("comments=%q %q", orders.Comment, orders.Column("amount").Comment)
+	}
+	fk := orders.ForeignKeys[0]
+	if !reflect.DeepEqual(fk.Columns, []string{"buyer_id"}) || !reflect.DeepEqual(fk.RefColumns, []string{"id"}) || fk.OnDelete != "SET NULL" {
+		t.Errorf("foreign key=%+v", fk)
+	}
+	if s.Table("ignored") != nil || len(s.Tables) != 2 {
+		t.Errorf("tables=%d", len(s.Tables))
+	}
+
+	src, err := render(Context{Tables: tablesOf(s), Package: "module", Quote: quoteOf("postgres")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := strings.Join(strings.Fields(string(src)), " ")
+	for _, want := range []string{
+		"ID int64 `dbx:\"column:id;primary_key;auto_increment\"",
+		"Tags pq.StringArray `",
+		"Payload json.RawMessage `",
+		"CreatedAt time.Time `",
+		`alias + "\"buyer_id\""`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("missing %s in:\n%s", want, src)
+		}
+	}
+}
+
+func Test_loadDDL(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"0001_init.up.sql":      "create table users (id integer primary key autoincrement, name text);",
+		"0001_init.down.sql":    "drop table users;",
+		"0002_orders.up.sql":    "create table orders (id integer primary key, user_id integer not null references users(id));",
+		"0003_name.up.sql":      "create index idx_users_name on users(name); alter table users add column email varchar(128) not null default '';",
+		"0003_name.down.sql":    "drop index idx_users_name;",
+		"README.md":             "ignored",
+		"schema/0001_users.sql": "create table users (id text primary key);",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scripts, err := readDDL([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scripts) != 3 {
+		t.Fatalf("scripts=%q", scripts)
+	}
+	s, err := loadDDL("sqlite3", scripts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := s.Table("users")
+	if users == nil || len(users.Columns) != 3 || !users.Column("id").AutoIncrement || len(users.Indexes) != 1 {
+		t.Fatalf("users=%+v", users)
+	}
+	if c := s.Table("orders").Column("id"); !c.AutoIncrement {
+		t.Errorf("orders.id=%+v", c)
+	}
+
+	// a directory without migrations is read as its .sql files.
+	scripts, err = readDDL([]string{filepath.Join(dir, "schema")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, err = loadDDL("sqlite3", scripts); err != nil {
+		t.Fatal(err)
+	}
+	if c := s.Table("users").Column("id"); c.AutoIncrement || c.DataType != "text" {
+		t.Errorf("users.id=%+v", c)
+	}
+}
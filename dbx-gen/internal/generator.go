@@ -2,17 +2,20 @@ package internal
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	_ "embed"
 	"errors"
 	"fmt"
 	"go/format"
-	"os"
 	"path"
+	"path/filepath"
+	"sort"
 	"strings"
 	"text/template"
 
-	"github.com/microbun/dbx"
+	"github.com/microbun/dbx/introspect"
+	"github.com/microbun/dbx/migrate"
 )
 
 var types = map[string]string{
@@ -41,18 +44,42 @@ var types = map[string]string{
 	"TEXT":       "string",
 	"ENUM":       "string",
 	"TINYINT(1)": "bool",
+	"JSON":       "json.RawMessage",
+
+	// the udt_name of the PostgreSQL types.
+	"INT2":        "int16",
+	"INT4":        "int32",
+	"INT8":        "int64",
+	"SMALLSERIAL": "int16",
+	"SERIAL":      "int32",
+	"BIGSERIAL":   "int64",
+	"FLOAT4":      "float32",
+	"FLOAT8":      "float64",
+	"BOOL":        "bool",
+	"BOOLEAN":     "bool",
+	"UUID":        "string",
+	"JSONB":       "json.RawMessage",
+	"BYTEA":       "[]byte",
+	"TIMESTAMPTZ": "time.Time",
+	"TIME":        "string",
+	"BPCHAR":      "string",
+	"CITEXT":      "string",
 
 	// "SET":   "",
 }
 
-type SQLite3Column struct {
-	ColumnID     int            `dbx:"column:cid" `
-	ColumnName   string         `dbx:"column:name" `
-	DataType     string         `dbx:"column:type" `
-	NotNull      int            `dbx:"column:notnull"`
-	DefaultValue sql.NullString `dbx:"column:dflt_value"`
-	ColumnKey    int            `dbx:"column:pk"`
+// arrayTypes are the github.com/lib/pq types of the PostgreSQL arrays by element type,
+// the other arrays are read as pq.StringArray.
+var arrayTypes = map[string]string{
+	"INT2":   "pq.Int64Array",
+	"INT4":   "pq.Int64Array",
+	"INT8":   "pq.Int64Array",
+	"FLOAT4": "pq.Float64Array",
+	"FLOAT8": "pq.Float64Array",
+	"BOOL":   "pq.BoolArray",
+	"BYTEA":  "pq.ByteaArray",
 }
+
 type Column struct {
 	TableName     string `dbx:"column:table_name" `
 	ColumnName    string `dbx:"column:column_name" `
@@ -83,10 +110,32 @@ func (t Table) ColumnString() string {
 }
 
 func (c Column) Name() string {
-	return toCamelInitCase(c.ColumnName, true)
+	if name, ok := Options.FieldNames[c.TableName+"."+c.ColumnName]; ok {
+		return name
+	}
+	if name, ok := Options.FieldNames[c.ColumnName]; ok {
+		return name
+	}
+	return goName(c.ColumnName)
 }
 
 func (c Column) Type() string {
+	typeName, _ := c.goType()
+	return typeName
+}
+
+// packages are the import paths of the packages of the built-in types.
+var packages = map[string]string{
+	"time": "time",
+	"json": "encoding/json",
+	"pq":   "github.com/lib/pq",
+}
+
+// goType returns the Go type of the column and the import path of its package, empty for the predeclared types.
+func (c Column) goType() (string, string) {
+	if t, ok := Options.ColumnTypes[c.TableName+"."+c.ColumnName]; ok {
+		return goType(t)
+	}
 	pointer := ""
 	if c.Nullable == "YES" {
 		pointer += "*"
@@ -95,10 +144,27 @@ func (c Column) Type() string {
 	if strings.ToUpper(c.ColumnType) == "TINYINT(1)" {
 		typeName = "bool"
 	}
-	if typeName == "[]byte" {
+	if strings.HasSuffix(c.DataType, "[]") {
+		typeName = arrayTypes[strings.ToUpper(strings.TrimSuffix(c.DataType, "[]"))]
+		if typeName == "" {
+			typeName = "pq.StringArray"
+		}
+	}
+	importPath := ""
+	if t, ok := Options.Types[strings.ToUpper(c.ColumnType)]; ok {
+		typeName, importPath = goType(t)
+	} else if t, ok := Options.Types[strings.ToUpper(c.DataType)]; ok {
+		typeName, importPath = goType(t)
+	}
+	if i := strings.Index(typeName, "."); importPath == "" && i >= 0 {
+		importPath = packages[strings.TrimLeft(typeName[:i], "*[]")]
+	}
+	// the slices, the maps and the pointers are nil for NULL.
+	if typeName == "json.RawMessage" || strings.HasPrefix(typeName, "pq.") ||
+		strings.HasPrefix(typeName, "[]") || strings.HasPrefix(typeName, "*") || strings.HasPrefix(typeName, "map[") {
 		pointer = ""
 	}
-	return pointer + typeName
+	return pointer + typeName, importPath
 }
 
 func (c Column) Tag() string {
@@ -121,7 +187,14 @@ func (c Column) Tag() string {
 	}
 
 	dbxTag += "\""
-	return fmt.Sprintf("`%v json:\"%v%v\" `", dbxTag, c.ColumnName, omitempty)
+	tags := []string{dbxTag, fmt.Sprintf("json:\"%v%v\"", c.ColumnName, omitempty)}
+	for _, key := range Options.Tags {
+		tags = append(tags, fmt.Sprintf("%v:\"%v%v\"", key, c.ColumnName, omitempty))
+	}
+	if tag, ok := Options.ColumnTags[c.TableName+"."+c.ColumnName]; ok {
+		tags = append(tags, tag)
+	}
+	return fmt.Sprintf("`%v `", strings.Join(tags, " "))
 }
 
 //go:embed module.go.tmpl
@@ -132,136 +205,221 @@ func Run() error {
 }
 
 type Context struct {
-	Tables  map[string]*Table
+	// Tables are sorted by name.
+	Tables  []*Table
 	Package string
+	// Quote is the quote of the identifiers in the Go string literals of the generated code.
+	Quote string
 }
 
+// quoteOf returns the Context.Quote of driver, the back quote of MySQL and SQLite and the double quote of PostgreSQL.
+func quoteOf(driver string) string {
+	if driver == introspect.Postgres {
+		return `\"`
+	}
+	return "`"
+}
+
+// Imports returns the sorted packages of the standard library imported by the generated code.
 func (c Context) Imports() []string {
-	ps := map[string]interface{}{}
+	return c.imports(true)
+}
+
+// ExternalImports returns the sorted packages imported by the generated code which are not in the standard library.
+func (c Context) ExternalImports() []string {
+	return c.imports(false)
+}
+
+func (c Context) imports(std bool) []string {
+	ps := map[string]interface{}{"encoding/json": nil, "fmt": nil, "strings": nil}
 	for _, table := range c.Tables {
 		for _, column := range table.Columns {
-			if column.Type() == "time.Time" || column.Type() == "*time.Time" {
-				ps["time"] = nil
+			if _, importPath := column.goType(); importPath != "" {
+				ps[importPath] = nil
 			}
 		}
 	}
 	var packages []string
 	for k := range ps {
-		packages = append(packages, k)
+		// the paths of the standard library have no dot in their first element.
+		if std == !strings.Contains(strings.Split(k, "/")[0], ".") {
+			packages = append(packages, k)
+		}
 	}
+	sort.Strings(packages)
 	return packages
 }
 
-func generate() error {
-	if strings.ToLower(Options.Driver) != "mysql" && strings.ToLower(Options.Driver) != "sqlite3" {
-		return errors.New("unsupport database")
-	}
-	db, err := dbx.Open(Options.Driver, Options.DataSourceName)
-	if err != nil {
-		return err
-	}
-	db.Options().Logger = nil
-	var columns []*Column
-	if strings.ToLower(Options.Driver) == "mysql" {
-		err = db.Query(&columns, `select table_name,column_name,column_comment,data_type,is_nullable,column_key,extra,column_type
-		from information_schema.columns
-		where TABLE_SCHEMA=?`, Options.Schema)
-		if err != nil {
-			return err
+// tablesOf returns the tables of schema sorted by name, with their columns sorted by position.
+func tablesOf(schema *introspect.Schema) []*Table {
+	var tables []*Table
+	for _, t := range schema.Tables {
+		table := &Table{
+			StructName: goName(t.Name),
+			TableName:  t.Name,
 		}
-	} else if strings.ToLower(Options.Driver) == "sqlite3" {
-		var tables = []string{}
-		var autoIncrementTables = []string{}
-		db.Query(&autoIncrementTables, "select name from sqlite_sequence")
-		err = db.Query(&tables, `select tbl_name from sqlite_master where type='table' and  tbl_name not in('sqlite_sequence');`)
-		if err != nil {
-			return err
+		positions := map[string]int{}
+		if name, ok := Options.StructNames[t.Name]; ok {
+			table.StructName = name
 		}
-		for _, table := range tables {
-			sqlite3Columns := []SQLite3Column{}
-			err := db.Query(&sqlite3Columns, "PRAGMA table_info("+table+")")
-			if err != nil {
-				return err
+		for _, c := range t.Columns {
+			positions[c.Name] = c.Position
+			column := Column{
+				TableName:     t.Name,
+				ColumnName:    c.Name,
+				ColumnComment: c.Comment,
+				DataType:      c.DataType,
+				Nullable:      "NO",
+				ColumnType:    c.ColumnType,
 			}
-			for _, column := range sqlite3Columns {
-
-				nullable := "YES"
-				if column.NotNull == 1 {
-					nullable = "NO"
-				}
-				isPK := ""
-				// isAutoIncrementPK := false
-				// for _, name := range autoIncrementTables {
-				// 	if table == name {
-				// 		isAutoIncrementPK = true
-				// 	}
-				// }
-				extra := ""
-				if column.ColumnKey == 1 {
-					isPK = "PRI"
-					nullable = "NO"
-					extra = "auto_increment"
-				}
-				columns = append(columns, &Column{
-					TableName:  table,
-					ColumnName: column.ColumnName,
-					DataType:   column.DataType,
-					Nullable:   nullable,
-					ColumnKey:  isPK,
-					Extra:      extra,
-				})
+			// the primary key of SQLite may be declared nullable but it is never null.
+			if c.Nullable && !c.PrimaryKey {
+				column.Nullable = "YES"
 			}
-		}
-	} else {
-		return errors.New("unsupport database")
-	}
-
-	tables := map[string]*Table{}
-
-	for _, column := range columns {
-		name := column.TableName
-		if v, ok := tables[name]; ok {
-			v.Columns = append(v.Columns, *column)
-		} else {
-			tables[name] = &Table{
-				StructName: toCamelInitCase(column.TableName, true),
-				TableName:  column.TableName,
-				Columns:    []Column{*column},
+			if c.PrimaryKey {
+				column.ColumnKey = "PRI"
 			}
+			if c.AutoIncrement {
+				column.Extra = "auto_increment"
+			}
+			table.Columns = append(table.Columns, column)
 		}
+		sort.SliceStable(table.Columns, func(i, j int) bool {
+			return positions[table.Columns[i].ColumnName] < positions[table.Columns[j].ColumnName]
+		})
+		tables = append(tables, table)
 	}
+	sort.Slice(tables, func(i, j int) bool {
+		return tables[i].TableName < tables[j].TableName
+	})
+	return tables
+}
 
-	tmpl := template.New("template")
-	tmpl, err = tmpl.Parse(string(text))
+// render executes the template and formats the source.
+func render(c Context) ([]byte, error) {
+	tmpl, err := template.New("template").Parse(text)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	buf := bytes.NewBuffer(make([]byte, 0))
+	if err = tmpl.Execute(buf, c); err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
 
-	err = tmpl.Execute(buf, Context{
-		Tables:  tables,
-		Package: Options.Package,
-	})
+// loadSchema reads the schema from the DDL files if there are some, from the database otherwise.
+func loadSchema(driver string) (*introspect.Schema, error) {
+	if len(Options.DDL) > 0 {
+		scripts, err := readDDL(Options.DDL)
+		if err != nil {
+			return nil, err
+		}
+		return loadDDL(driver, scripts)
+	}
+	db, err := sql.Open(driver, Options.DataSourceName)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer db.Close()
+	return introspect.Load(context.Background(), db, driver, Options.Schema)
+}
 
-	dir := path.Dir(Options.Output)
-	err = os.MkdirAll(dir, os.ModePerm)
-	if err != nil {
-		return err
+func generate() error {
+	driver := strings.ToLower(Options.Driver)
+	if driver != introspect.MySQL && driver != introspect.Postgres && driver != introspect.SQLite {
+		return errors.New("unsupport database")
 	}
-	out, err := os.OpenFile(Options.Output, os.O_CREATE|os.O_RDWR, os.ModePerm)
+	schema, err := loadSchema(driver)
 	if err != nil {
 		return err
 	}
-	src, err := format.Source(buf.Bytes())
+	tables, err := filterTables(schema.Tables, Options.Tables, Options.Exclude)
 	if err != nil {
 		return err
 	}
-	_, err = out.Write(src)
+	files, err := outputs(tablesOf(&introspect.Schema{Tables: tables}), driver)
 	if err != nil {
 		return err
 	}
-	return nil
+	var stale []string
+	if Options.Split {
+		if stale, err = staleFiles(splitDir(), files); err != nil {
+			return err
+		}
+	}
+	if Options.Check {
+		return checkFiles(files, stale)
+	}
+	return writeFiles(files, stale)
+}
+
+// internalTables are the tables of the migration tools, they are skipped unless they are selected by name.
+var internalTables = []string{migrate.DefaultTable, migrate.DefaultTable + "_lock", "schema_migrations", "goose_db_version", "sqlite_*"}
+
+// filterTables returns the tables matching a pattern of include, or every table if it is empty,
+// and no pattern of exclude. The patterns are the ones of path.Match.
+func filterTables(tables []*introspect.Table, include []string, exclude []string) ([]*introspect.Table, error) {
+	var selected []*introspect.Table
+	for _, t := range tables {
+		included := len(include) == 0
+		explicit := false
+		for _, pattern := range include {
+			ok, err := path.Match(pattern, t.Name)
+			if err != nil {
+				return nil, err
+			}
+			included = included || ok
+			explicit = explicit || ok && pattern == t.Name
+		}
+		excluded := false
+		for _, pattern := range exclude {
+			ok, err := path.Match(pattern, t.Name)
+			if err != nil {
+				return nil, err
+			}
+			excluded = excluded || ok
+		}
+		if !explicit {
+			for _, pattern := range internalTables {
+				if ok, _ := path.Match(pattern, t.Name); ok {
+					excluded = true
+				}
+			}
+		}
+		if included && !excluded {
+			selected = append(selected, t)
+		}
+	}
+	return selected, nil
+}
+
+// outputs returns the generated sources by file name: the Output file,
+// or a <table>.gen.go file per table in the Output directory when Options.Split is set.
+func outputs(tables []*Table, driver string) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	if !Options.Split {
+		src, err := render(Context{Tables: tables, Package: Options.Package, Quote: quoteOf(driver)})
+		if err != nil {
+			return nil, err
+		}
+		files[Options.Output] = src
+		return files, nil
+	}
+	for _, t := range tables {
+		src, err := render(Context{Tables: []*Table{t}, Package: Options.Package, Quote: quoteOf(driver)})
+		if err != nil {
+			return nil, err
+		}
+		files[filepath.Join(splitDir(), t.TableName+".gen.go")] = src
+	}
+	return files, nil
+}
+
+// splitDir returns the directory of the files of Options.Split, the directory of Options.Output if it is a .go file.
+func splitDir() string {
+	if strings.HasSuffix(Options.Output, ".go") {
+		return filepath.Dir(Options.Output)
+	}
+	return Options.Output
 }
@@ -1,6 +1,15 @@
 package internal
 
-import "testing"
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/microbun/dbx/introspect"
+)
 
 func Test_Generate(t *testing.T) {
 
@@ -19,3 +28,121 @@ func Test_Generate(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func Test_tablesOf(t *testing.T) {
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "gen.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, ddl := range []string{
+		"create table users (id integer primary key, name varchar(64), created_at datetime not null)",
+		"create table tags (name text primary key, weight bigint)",
+	} {
+		if _, err = db.Exec(ddl); err != nil {
+			t.Fatal(err)
+		}
+	}
+	schema, err := introspect.Load(context.Background(), db, "sqlite3", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables := tablesOf(schema)
+	if len(tables) != 2 || tables[0].TableName != "tags" {
+		t.Fatalf("tables are not sorted: %+v", tables)
+	}
+	users := tables[1]
+	if users.StructName != "Users" || len(users.Columns) != 3 {
+		t.Fatalf("users=%+v", users)
+	}
+	if tag := users.Columns[0].Tag(); !strings.Contains(tag, "primary_key;auto_increment") {
+		t.Errorf("id tag=%s", tag)
+	}
+	if typ := users.Columns[1].Type(); typ != "*string" {
+		t.Errorf("name type=%s", typ)
+	}
+	if typ := users.Columns[2].Type(); typ != "time.Time" {
+		t.Errorf("created_at type=%s", typ)
+	}
+	if tag := tables[0].Columns[0].Tag(); strings.Contains(tag, "auto_increment") {
+		t.Errorf("text primary key tag=%s", tag)
+	}
+}
+
+func Test_render_Postgres(t *testing.T) {
+	nextval := "nextval('orders_id_seq'::regclass)"
+	schema := &introspect.Schema{Tables: []*introspect.Table{{
+		Name: "orders",
+		Columns: []*introspect.Column{
+			{Name: "id", DataType: "int4", ColumnType: "integer", PrimaryKey: true, AutoIncrement: true, Default: &nextval},
+			{Name: "uid", DataType: "uuid", ColumnType: "uuid"},
+			{Name: "payload", DataType: "jsonb", ColumnType: "jsonb", Nullable: true},
+			{Name: "tags", DataType: "text[]", ColumnType: "text[]", Nullable: true},
+			{Name: "scores", DataType: "int8[]", ColumnType: "bigint[]"},
+			{Name: "amount", DataType: "numeric", ColumnType: "numeric(10,2)", Nullable: true},
+			{Name: "paid", DataType: "bool", ColumnType: "boolean"},
+			{Name: "data", DataType: "bytea", ColumnType: "bytea", Nullable: true},
+			{Name: "created_at", DataType: "timestamptz", ColumnType: "timestamp with time zone"},
+		},
+		PrimaryKey: []string{"id"},
+	}}}
+	src, err := render(Context{Tables: tablesOf(schema), Package: "module", Quote: quoteOf("postgres")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`"github.com/lib/pq"`,
+		`"time"`,
+		`alias = "\"" + alias + "\"" + "."`,
+		`alias + "\"id\""`,
+		`" select count(1) from \"orders\" "`,
+		"ID int32 `dbx:\"column:id;primary_key;auto_increment\"",
+		"Uid string `",
+		"Payload json.RawMessage `",
+		"Tags pq.StringArray `",
+		"Scores pq.Int64Array `",
+		"Amount *float64 `",
+		"Paid bool `",
+		"Data []byte `",
+		"CreatedAt time.Time `",
+	} {
+		// the fields are aligned by gofmt.
+		if !strings.Contains(strings.Join(strings.Fields(string(src)), " "), want) {
+			t.Errorf("missing %s in:\n%s", want, src)
+		}
+	}
+}
+
+func Test_filterTables(t *testing.T) {
+	var tables []*introspect.Table
+	for _, name := range []string{"dbx_migrations", "order_items", "orders", "schema_migrations", "tmp_orders", "users"} {
+		tables = append(tables, &introspect.Table{Name: name})
+	}
+	tests := []struct {
+		include []string
+		exclude []string
+		want    string
+	}{
+		{want: "order_items orders tmp_orders users"},
+		{include: []string{"order*"}, want: "order_items orders"},
+		{exclude: []string{"tmp_*", "users"}, want: "order_items orders"},
+		{include: []string{"*orders"}, exclude: []string{"tmp_*"}, want: "orders"},
+		{include: []string{"users", "schema_migrations"}, want: "schema_migrations users"},
+	}
+	for _, test := range tests {
+		selected, err := filterTables(tables, test.include, test.exclude)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, table := range selected {
+			names = append(names, table.Name)
+		}
+		if got := strings.Join(names, " "); got != test.want {
+			t.Errorf("filterTables(%v, %v)=%s, want %s", test.include, test.exclude, got, test.want)
+		}
+	}
+	if _, err := filterTables(tables, []string{"["}, nil); err == nil {
+		t.Error("expected an error for a malformed pattern")
+	}
+}
@@ -6,4 +6,23 @@ var Options struct {
 	Driver         string
 	DataSourceName string
 	Schema         string
+	// DDL are the files and the directories of migrations to generate from instead of the database.
+	DDL []string
+	// Tables are the glob patterns of the tables to generate, every table if empty.
+	Tables []string
+	// Exclude are the glob patterns of the tables not to generate.
+	Exclude []string
+	// Split writes a <table>.gen.go file per table in the Output directory.
+	Split bool
+	// Check compares the generated code with the output file instead of writing it.
+	Check bool
+
+	// The following options are set by the configuration file, see Config.
+	Types       map[string]string
+	ColumnTypes map[string]string
+	StructNames map[string]string
+	FieldNames  map[string]string
+	Acronyms    []string
+	Tags        []string
+	ColumnTags  map[string]string
 }
@@ -0,0 +1,247 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// writeFile replaces the file name by src atomically: src is written to a temporary file of the same directory
+// which is renamed to name, so a failed or concurrent generation never leaves a truncated file.
+func writeFile(name string, src []byte) error {
+	dir := filepath.Dir(name)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(src); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+// header is the first line of the generated files.
+const header = "// Code generated by dbx-generator. DO NOT EDIT."
+
+// staleFiles returns the .gen.go files of dir carrying the header which are not in files,
+// the files of the tables which are not generated anymore.
+func staleFiles(dir string, files map[string][]byte) ([]string, error) {
+	names, err := filepath.Glob(filepath.Join(dir, "*.gen.go"))
+	if err != nil {
+		return nil, err
+	}
+	var stale []string
+	for _, name := range names {
+		if _, ok := files[name]; ok {
+			continue
+		}
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		if bytes.HasPrefix(b, []byte(header)) {
+			stale = append(stale, name)
+		}
+	}
+	return stale, nil
+}
+
+// writeFiles writes the files and removes the stale ones.
+func writeFiles(files map[string][]byte, stale []string) error {
+	for _, name := range sortedNames(files) {
+		if err := writeFile(name, files[name]); err != nil {
+			return err
+		}
+	}
+	for _, name := range stale {
+		if err := os.Remove(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkFiles returns an error with the unified diffs of the files which are not up to date
+// and the stale files which would be removed.
+func checkFiles(files map[string][]byte, stale []string) error {
+	var diffs []string
+	for _, name := range sortedNames(files) {
+		current, err := os.ReadFile(name)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if !bytes.Equal(current, files[name]) {
+			diffs = append(diffs, unifiedDiff(name, string(current), string(files[name])))
+		}
+	}
+	for _, name := range stale {
+		diffs = append(diffs, fmt.Sprintf("%s is stale\n", name))
+	}
+	if len(diffs) > 0 {
+		return fmt.Errorf("the generated code is out of date, run dbx-gen:\n%s", strings.Join(diffs, ""))
+	}
+	return nil
+}
+
+func sortedNames(files map[string][]byte) []string {
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// diffContext is the number of unchanged lines around the changes of a hunk.
+const diffContext = 3
+
+// diffLine is a line of a diff prefixed by ' ', '-' or '+'.
+type diffLine struct {
+	op   byte
+	text string
+	// a and b are the 0-based numbers of the line in the old and the new text.
+	a, b int
+}
+
+// unifiedDiff returns the differences between the lines of a and b in the unified format, empty if they are equal.
+func unifiedDiff(name string, a string, b string) string {
+	lines := diffLines(splitLines(a), splitLines(b))
+	var out strings.Builder
+	for i := 0; i < len(lines); {
+		if lines[i].op == ' ' {
+			i++
+			continue
+		}
+		// a hunk spans the changes separated by less than 2*diffContext unchanged lines.
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for unchanged := 0; end < len(lines) && unchanged <= 2*diffContext; end++ {
+			if lines[end].op == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+		}
+		for end > i && lines[end-1].op == ' ' {
+			end--
+		}
+		if end += diffContext; end > len(lines) {
+			end = len(lines)
+		}
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s (generated)\n", name, name)
+		}
+		writeHunk(&out, lines[start:end])
+		i = end
+	}
+	return out.String()
+}
+
+func writeHunk(out *strings.Builder, lines []diffLine) {
+	aStart, bStart, aCount, bCount := -1, -1, 0, 0
+	for _, l := range lines {
+		if l.op != '+' {
+			if aStart < 0 {
+				aStart = l.a
+			}
+			aCount++
+		}
+		if l.op != '-' {
+			if bStart < 0 {
+				bStart = l.b
+			}
+			bCount++
+		}
+	}
+	// an empty range starts at the line before it.
+	if aStart < 0 {
+		aStart = lines[0].a - 1
+	}
+	if bStart < 0 {
+		bStart = lines[0].b - 1
+	}
+	fmt.Fprintf(out, "@@ -%d,%d +%d,%d @@\n", aStart+1, aCount, bStart+1, bCount)
+	for _, l := range lines {
+		out.WriteByte(l.op)
+		out.WriteString(l.text)
+		out.WriteByte('\n')
+	}
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines returns the lines of a and b aligned on their longest common subsequence.
+func diffLines(a []string, b []string) []diffLine {
+	// the common prefix and suffix are kept out of the quadratic table.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	ma, mb := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	// lcs[i][j] is the length of the longest common subsequence of ma[i:] and mb[j:].
+	lcs := make([][]int32, len(ma)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(mb)+1)
+	}
+	for i := len(ma) - 1; i >= 0; i-- {
+		for j := len(mb) - 1; j >= 0; j-- {
+			if ma[i] == mb[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var lines []diffLine
+	for i := 0; i < prefix; i++ {
+		lines = append(lines, diffLine{op: ' ', text: a[i], a: i, b: i})
+	}
+	i, j := 0, 0
+	for i < len(ma) || j < len(mb) {
+		switch {
+		case i < len(ma) && j < len(mb) && ma[i] == mb[j]:
+			lines = append(lines, diffLine{op: ' ', text: ma[i], a: prefix + i, b: prefix + j})
+			i++
+			j++
+		case j == len(mb) || i < len(ma) && lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, diffLine{op: '-', text: ma[i], a: prefix + i, b: prefix + j})
+			i++
+		default:
+			lines = append(lines, diffLine{op: '+', text: mb[j], a: prefix + i, b: prefix + j})
+			j++
+		}
+	}
+	for k := 0; k < suffix; k++ {
+		lines = append(lines, diffLine{op: ' ', text: a[len(a)-suffix+k], a: len(a) - suffix + k, b: len(b) - suffix + k})
+	}
+	return lines
+}
@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_unifiedDiff(t *testing.T) {
+	a := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+	b := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\nn\n"
+	want := `--- x.go
++++ x.go (generated)
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -11,3 +11,4 @@
+ k
+ l
+ m
++n
+`
+	if got := unifiedDiff("x.go", a, b); got != want {
+		t.Errorf("diff:\n%s", got)
+	}
+	if got := unifiedDiff("x.go", a, a); got != "" {
+		t.Errorf("diff of equal texts:\n%s", got)
+	}
+	if got := unifiedDiff("x.go", "", "a\n"); got != "--- x.go\n+++ x.go (generated)\n@@ -0,0 +1,1 @@\n+a\n" {
+		t.Errorf("diff of a new file:\n%s", got)
+	}
+}
+
+func Test_writeFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "module", "module.gen.go")
+	if err := writeFile(name, []byte("package module\n\nvar a = 1\n")); err != nil {
+		t.Fatal(err)
+	}
+	src := []byte("package module\n")
+	if err := writeFile(name, src); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(name); string(b) != string(src) {
+		t.Errorf("content=%q", b)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(name)); len(entries) != 1 {
+		t.Errorf("temporary files are left: %v", entries)
+	}
+
+	if err := checkFiles(map[string][]byte{name: src}, nil); err != nil {
+		t.Error(err)
+	}
+	err := checkFiles(map[string][]byte{name: []byte("package other\n")}, nil)
+	if err == nil || !strings.Contains(err.Error(), "-package module\n+package other\n") {
+		t.Errorf("check err=%v", err)
+	}
+	if err = checkFiles(map[string][]byte{name + ".missing": src}, nil); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func Test_staleFiles(t *testing.T) {
+	dir := t.TempDir()
+	generated := []byte(header + "\npackage module\n")
+	for name, content := range map[string][]byte{
+		"users.gen.go":   generated,
+		"dropped.gen.go": generated,
+		"custom.gen.go":  []byte("package module\n"),
+		"users.go":       generated,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string][]byte{filepath.Join(dir, "users.gen.go"): generated, filepath.Join(dir, "orders.gen.go"): generated}
+	stale, err := staleFiles(dir, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stale) != 1 || filepath.Base(stale[0]) != "dropped.gen.go" {
+		t.Fatalf("stale=%v", stale)
+	}
+	if err = checkFiles(files, stale); err == nil || !strings.Contains(err.Error(), "dropped.gen.go is stale") {
+		t.Errorf("check err=%v", err)
+	}
+	if err = writeFiles(files, stale); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if strings.Join(names, " ") != "custom.gen.go orders.gen.go users.gen.go users.go" {
+		t.Errorf("files=%v", names)
+	}
+	if err = checkFiles(files, nil); err != nil {
+		t.Error(err)
+	}
+}
@@ -22,7 +22,12 @@ func toCamelInitCase(s string, initCase bool) string {
 	if v, ok := abbr[s]; ok {
 		return v
 	}
+	return camelCase(s, initCase)
+}
 
+// camelCase joins the words of s, the first letter of every word but the first is upper case,
+// the first letter of s is upper case if initCase is set.
+func camelCase(s string, initCase bool) string {
 	n := strings.Builder{}
 	n.Grow(len(s))
 	capNext := initCase
@@ -52,3 +57,39 @@ func toCamelInitCase(s string, initCase bool) string {
 	}
 	return n.String()
 }
+
+// goName returns the Go name of a table or a column, the words of Options.Acronyms are written in upper case.
+// A name without such a word is the same as without Options.Acronyms.
+func goName(s string) string {
+	name := toCamelInitCase(s, true)
+	if len(Options.Acronyms) == 0 {
+		return name
+	}
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return r == '_' || r == ' ' || r == '-' || r == '.'
+	})
+	n := strings.Builder{}
+	found := false
+	for _, word := range words {
+		if a, ok := acronym(word); ok {
+			n.WriteString(a)
+			found = true
+		} else {
+			n.WriteString(camelCase(word, true))
+		}
+	}
+	if !found {
+		return name
+	}
+	return n.String()
+}
+
+// acronym returns the upper case of word if it is one of Options.Acronyms.
+func acronym(word string) (string, bool) {
+	for _, a := range Options.Acronyms {
+		if strings.EqualFold(word, a) {
+			return strings.ToUpper(a), true
+		}
+	}
+	return "", false
+}
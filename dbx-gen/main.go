@@ -3,9 +3,12 @@ package main
 import (
 	"flag"
 	"fmt"
+	"os"
+	"strings"
 	"time"
 
 	_ "github.com/go-sql-driver/mysql" //justifying
+	_ "github.com/lib/pq"              //justifying
 	_ "github.com/mattn/go-sqlite3"    //justifying
 	"github.com/microbun/dbx/dbx-gen/internal"
 )
@@ -18,19 +21,71 @@ type Avatar struct {
 	DeletedAt *time.Time `dbx:"column:deleted_at"`
 }
 
+// stringsFlag is a flag which may be repeated.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// globsFlag is a list of comma separated patterns, the flag may be repeated.
+type globsFlag []string
+
+func (f *globsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *globsFlag) Set(value string) error {
+	for _, glob := range strings.Split(value, ",") {
+		if glob = strings.TrimSpace(glob); glob != "" {
+			*f = append(*f, glob)
+		}
+	}
+	return nil
+}
+
 func main() {
 	flag.StringVar(&internal.Options.Package, "p", "module", "Golang package name")
-	flag.StringVar(&internal.Options.Output, "o", "module.gen.go", "Write output to a `file`")
+	flag.StringVar(&internal.Options.Output, "o", "module.gen.go", "Write output to a `file`, or to a directory with -split")
 	flag.StringVar(&internal.Options.Driver, "driver", "mysql", "Database driver name")
 	flag.StringVar(&internal.Options.DataSourceName, "uri", "", "Data source name")
 	flag.StringVar(&internal.Options.Schema, "schema", "", "Database schema")
+	flag.Var((*stringsFlag)(&internal.Options.DDL), "ddl", "Generate from the DDL `file` or directory of migrations instead of the database, repeatable")
+	flag.Var((*globsFlag)(&internal.Options.Tables), "tables", "Generate only the tables matching the comma separated glob `patterns`")
+	flag.Var((*globsFlag)(&internal.Options.Exclude), "exclude", "Skip the tables matching the comma separated glob `patterns`")
+	flag.BoolVar(&internal.Options.Split, "split", false, "Write a <table>.gen.go file per table and remove the generated files of the dropped tables")
+	flag.BoolVar(&internal.Options.Check, "check", false, "Exit with the diff if the output file is not up to date instead of writing it")
+	config := flag.String("config", "", "Read the options from the YAML or JSON `file`, dbx-gen.yaml, dbx-gen.yml or dbx-gen.json by default; the flags take precedence")
 	flag.Parse()
 	if !flag.Parsed() {
 		flag.PrintDefaults()
 		return
 	}
+	if *config == "" {
+		*config = internal.FindConfig()
+	}
+	if *config != "" {
+		c, err := internal.ReadConfig(*config)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		explicit := map[string]bool{}
+		flag.Visit(func(f *flag.Flag) {
+			explicit[f.Name] = true
+		})
+		c.Merge(func(name string) bool {
+			return explicit[name]
+		})
+	}
 	err := internal.Run()
 	if err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 }
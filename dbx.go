@@ -7,7 +7,35 @@ import (
 )
 
 type Options struct {
-	Logger     Logger
+	Logger Logger
+	// QueryLogger receives a QueryEvent after every statement has been executed.
+	QueryLogger QueryLogger
+	// LogLevel decides which statements are sent to Logger and QueryLogger.
+	LogLevel LogLevel
+	// SlowQueryThreshold is the duration from which a statement is considered slow,
+	// DefaultSlowQueryThreshold is used if it is zero.
+	SlowQueryThreshold time.Duration
+	// Interceptors wrap every prepare, exec, query and transaction operation, the first one is the outermost.
+	Interceptors []Interceptor
+	// RedactArgs hides the arguments of statements in *QueryError.
+	RedactArgs bool
+	// StmtCacheSize is the number of prepared statements cached by DB and by every Tx,
+	// the statements are prepared for every call if it is zero.
+	StmtCacheSize int
+	// NoPrepare sends the statements to the database without preparing them.
+	NoPrepare bool
+	// NamedStyle is the set of prefixes of named parameters, NamedColon if it is zero.
+	NamedStyle NamedStyle
+	// Balancer picks the replica executing a read in a cluster opened by OpenCluster,
+	// the replicas are picked in turn if it is nil.
+	Balancer Balancer
+	// HealthCheckInterval is the interval between two pings of the replicas of a cluster,
+	// DefaultHealthCheckInterval is used if it is zero.
+	HealthCheckInterval time.Duration
+	// TablePrefix is added to the table names of the structs, after the schema of a qualified name.
+	TablePrefix string
+	// Dialect classifies driver errors, it is detected from the driver name by Open.
+	Dialect    Dialect
 	Generator  SQLGenerator
 	Location   *time.Location
 	TimeFormat func(t *time.Time) string
@@ -29,21 +57,23 @@ func newDBX(db *sql.DB, options *Options) *DB {
 	return &DB{executor: exec, option: options, rawDB: db}
 }
 
-//Connect a database to dbx
+// Connect a database to dbx
 func Connect(db *sql.DB) *DB {
 	return newDBX(db, nil)
 }
 
-//Open a database
+// Open a database
 func Open(driverName string, dataSourceName string) (*DB, error) {
 	db, err := sql.Open(driverName, dataSourceName)
 	if err != nil {
 		return nil, err
 	}
 	return newDBX(db, &Options{
-		Logger:    logger,
-		Generator: NewCommonSQLGenerator(),
-		Location:  time.Local,
+		Logger:        logger,
+		Dialect:       dialectOf(driverName),
+		Generator:     NewCommonSQLGenerator(),
+		Location:      time.Local,
+		StmtCacheSize: DefaultStmtCacheSize,
 	}), nil
 }
 
@@ -51,22 +81,28 @@ func (d *DB) Options() *Options {
 	return d.option
 }
 
-//BeginTx begin a Transaction with opts
+// BeginTx begin a Transaction with opts
 func (d *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
-	tx, err := d.rawDB.BeginTx(ctx, opts)
+	var tx *sql.Tx
+	inv := &Invocation{Op: OpBegin, RowsAffected: -1}
+	err := d.option.intercept(ctx, inv, func(ctx context.Context, inv *Invocation) (err error) {
+		tx, err = d.rawDB.BeginTx(ctx, opts)
+		return d.option.translateError(err)
+	})
 	if err != nil {
 		return nil, err
 	}
-	return newTx(tx, d.option), nil
+	return newTx(ctx, tx, d.option), nil
 }
 
-//Begin a Transaction
+// Begin a Transaction
 func (d *DB) Begin() (*Tx, error) {
 	return d.BeginTx(context.Background(), nil)
 }
 
-//Transaction begin a transaction and commit automatically,automatically roll back when there is an error.
-func (d *DB) Transaction(fn func(*Tx) error) error {
+// Transaction begin a transaction and commit automatically,automatically roll back when there is an error.
+// If an OnRollback callback fails after fn returned an error, a *CallbackError caused by that error is returned.
+func (d *DB) Transaction(fn func(*Tx) error) (err error) {
 	tx, err := d.Begin()
 	if err != nil {
 		return err
@@ -74,7 +110,13 @@ func (d *DB) Transaction(fn func(*Tx) error) error {
 
 	defer func() {
 		txErr := tx.Rollback()
-		if txErr != sql.ErrTxDone {
+		if txErr == nil || txErr == sql.ErrTxDone {
+			return
+		}
+		if cbErr, ok := txErr.(*CallbackError); ok {
+			cbErr.Cause = err
+			err = cbErr
+		} else if err == nil {
 			err = txErr
 		}
 	}()
@@ -92,10 +134,15 @@ func (d *DB) Transaction(fn func(*Tx) error) error {
 // It is rare to Close a DB, as the DB handle is meant to be
 // long-lived and shared between many goroutines.
 func (d *DB) Close() error {
-	return d.rawDB.Close()
+	d.cache.close()
+	err := d.replicas.close()
+	if e := d.rawDB.Close(); e != nil {
+		return e
+	}
+	return err
 }
 
-//RawDB return a raw sql.DB object
+// RawDB return a raw sql.DB object
 func (d *DB) RawDB() *sql.DB {
 	return d.rawDB
 }
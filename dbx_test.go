@@ -148,7 +148,7 @@ func TestExecutor_MustQuery(t *testing.T) {
 	//})
 }
 
-func Example_MustNamedQuery() {
+func Example_mustNamedQuery() {
 	var examples = make([]*ExampleRecord, 0)
 	now := time.Now()
 	db.MustNamedQuery(&examples, "select * from example where `datetime`<? order by id desc", map[string]interface{}{
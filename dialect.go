@@ -0,0 +1,46 @@
+package dbx
+
+import "strings"
+
+// Dialect contains the behaviours that differ between databases.
+type Dialect interface {
+	// Name returns the driver name of the dialect.
+	Name() string
+	// TranslateError returns a *DriverError if err is a known driver error, otherwise err is returned.
+	TranslateError(err error) error
+	// LimitOffset returns the clause appended to a query to select limit rows after offset rows.
+	LimitOffset(limit, offset int) string
+	// Quote returns the quoted identifier, like a table or a schema name.
+	Quote(identifier string) string
+}
+
+var (
+	// MySQL is the dialect of github.com/go-sql-driver/mysql.
+	MySQL Dialect = mysqlDialect{}
+	// SQLite is the dialect of github.com/mattn/go-sqlite3.
+	SQLite Dialect = sqliteDialect{}
+)
+
+var dialects = []Dialect{MySQL, SQLite}
+
+// dialectOf returns the Dialect of driverName, or nil if it is unknown.
+func dialectOf(driverName string) Dialect {
+	for _, d := range dialects {
+		if strings.EqualFold(d.Name(), driverName) {
+			return d
+		}
+	}
+	return nil
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string {
+	return "mysql"
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string {
+	return "sqlite3"
+}
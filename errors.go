@@ -0,0 +1,217 @@
+package dbx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// ErrNotFound is returned when a query selects no rows, it is sql.ErrNoRows.
+var ErrNotFound = sql.ErrNoRows
+
+// ErrUnsupportedDialect is returned by the operations reading the schema of the database, like AutoMigrate,
+// for a database without a Dialect.
+var ErrUnsupportedDialect = errors.New("dbx: unsupported dialect")
+
+var (
+	ErrUniqueViolation     = errors.New("unique constraint violation")
+	ErrForeignKeyViolation = errors.New("foreign key constraint violation")
+	ErrNotNullViolation    = errors.New("not null constraint violation")
+	ErrDeadlock            = errors.New("deadlock")
+	ErrLockTimeout         = errors.New("lock timeout")
+)
+
+// DriverError is a driver error classified as one of ErrUniqueViolation, ErrForeignKeyViolation,
+// ErrNotNullViolation, ErrDeadlock or ErrLockTimeout.
+// errors.Is reports true for both the Kind and the original driver error.
+type DriverError struct {
+	Kind error
+	// Constraint is the name of the violated constraint or index, if the driver reports it.
+	Constraint string
+	// Column is the offending column, if the driver reports it.
+	Column string
+	Err    error
+}
+
+func (e *DriverError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *DriverError) Unwrap() error {
+	return e.Err
+}
+
+func (e *DriverError) Is(target error) bool {
+	return target == e.Kind
+}
+
+// QueryError is returned when a statement fails, it carries the statement so that the error is actionable.
+// errors.Is and errors.As see through it to the DriverError and the original driver error.
+type QueryError struct {
+	Op  Operation
+	SQL string
+	// Args are the arguments of the statement, nil if Options.RedactArgs is set.
+	Args []interface{}
+	// Table is the table name when the statement comes from a struct operation like Insert or Update.
+	Table string
+	Err   error
+}
+
+func (e *QueryError) Error() string {
+	op := string(e.Op)
+	if e.Table != "" {
+		op += " " + e.Table
+	}
+	if e.Args == nil {
+		return fmt.Sprintf("%s: %v (sql: %s)", op, e.Err, e.SQL)
+	}
+	return fmt.Sprintf("%s: %v (sql: %s, args: %v)", op, e.Err, e.SQL, e.Args)
+}
+
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
+
+// queryError wraps err of a statement in a *QueryError,
+// sql.ErrNoRows is returned unchanged so that it can still be compared with ==.
+func (o *Options) queryError(ctx context.Context, op Operation, query string, args []interface{}, err error) error {
+	if err == nil || err == sql.ErrNoRows {
+		return err
+	}
+	if o.RedactArgs {
+		args = nil
+	}
+	return &QueryError{Op: op, SQL: query, Args: args, Table: tableFromContext(ctx), Err: err}
+}
+
+// IsNotFound reports whether err is caused by a query which selects no rows.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// IsUniqueViolation reports whether err is caused by a duplicate key.
+func IsUniqueViolation(err error) bool {
+	return errors.Is(err, ErrUniqueViolation)
+}
+
+// IsForeignKeyViolation reports whether err is caused by a foreign key constraint.
+func IsForeignKeyViolation(err error) bool {
+	return errors.Is(err, ErrForeignKeyViolation)
+}
+
+// IsNotNullViolation reports whether err is caused by a null value in a not null column.
+func IsNotNullViolation(err error) bool {
+	return errors.Is(err, ErrNotNullViolation)
+}
+
+// IsDeadlock reports whether err is caused by a deadlock.
+func IsDeadlock(err error) bool {
+	return errors.Is(err, ErrDeadlock)
+}
+
+// IsLockTimeout reports whether err is caused by a timeout waiting for a lock.
+func IsLockTimeout(err error) bool {
+	return errors.Is(err, ErrLockTimeout)
+}
+
+// translateError classifies err with the Dialect of options, all known dialects are tried if it is not set.
+func (o *Options) translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if o.Dialect != nil {
+		return o.Dialect.TranslateError(err)
+	}
+	for _, d := range dialects {
+		if e := d.TranslateError(err); e != err {
+			return e
+		}
+	}
+	return err
+}
+
+// driverError finds the error of type pkgPath.name in the chain of err and returns its struct value.
+// The drivers are inspected by reflection, so dbx does not link them.
+func driverError(err error, pkgPath string, name string) (reflect.Value, bool) {
+	for ; err != nil; err = errors.Unwrap(err) {
+		v := reflect.Indirect(reflect.ValueOf(err))
+		if v.Kind() == reflect.Struct && v.Type().PkgPath() == pkgPath && v.Type().Name() == name {
+			return v, true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+var (
+	mysqlKey        = regexp.MustCompile("for key '([^']+)'")
+	mysqlConstraint = regexp.MustCompile("CONSTRAINT `([^`]+)`")
+	mysqlForeignKey = regexp.MustCompile("FOREIGN KEY \\(`([^`]+)`")
+	mysqlColumn     = regexp.MustCompile("(?:Column|Field) '([^']+)'")
+)
+
+func submatch(re *regexp.Regexp, s string) string {
+	m := re.FindStringSubmatch(s)
+	if len(m) < 2 {
+		return ""
+	}
+	return m[1]
+}
+
+func (mysqlDialect) TranslateError(err error) error {
+	v, ok := driverError(err, "github.com/go-sql-driver/mysql", "MySQLError")
+	if !ok {
+		return err
+	}
+	msg := v.FieldByName("Message").String()
+	switch v.FieldByName("Number").Uint() {
+	case 1062, 1586:
+		return &DriverError{Kind: ErrUniqueViolation, Constraint: submatch(mysqlKey, msg), Err: err}
+	case 1216, 1217, 1451, 1452:
+		return &DriverError{Kind: ErrForeignKeyViolation, Constraint: submatch(mysqlConstraint, msg), Column: submatch(mysqlForeignKey, msg), Err: err}
+	case 1048, 1364:
+		return &DriverError{Kind: ErrNotNullViolation, Column: submatch(mysqlColumn, msg), Err: err}
+	case 1213:
+		return &DriverError{Kind: ErrDeadlock, Err: err}
+	case 1205:
+		return &DriverError{Kind: ErrLockTimeout, Err: err}
+	}
+	return err
+}
+
+// sqliteColumn returns the columns of a message like "UNIQUE constraint failed: accounts.nickname".
+func sqliteColumn(msg string) string {
+	i := strings.LastIndex(msg, ": ")
+	if i < 0 {
+		return ""
+	}
+	columns := strings.Split(msg[i+2:], ", ")
+	for j, column := range columns {
+		columns[j] = column[strings.LastIndex(column, ".")+1:]
+	}
+	return strings.Join(columns, ",")
+}
+
+func (sqliteDialect) TranslateError(err error) error {
+	v, ok := driverError(err, "github.com/mattn/go-sqlite3", "Error")
+	if !ok {
+		return err
+	}
+	msg := err.Error()
+	switch v.FieldByName("ExtendedCode").Int() {
+	case 2067, 1555: // SQLITE_CONSTRAINT_UNIQUE, SQLITE_CONSTRAINT_PRIMARYKEY
+		return &DriverError{Kind: ErrUniqueViolation, Column: sqliteColumn(msg), Err: err}
+	case 787: // SQLITE_CONSTRAINT_FOREIGNKEY
+		return &DriverError{Kind: ErrForeignKeyViolation, Err: err}
+	case 1299: // SQLITE_CONSTRAINT_NOTNULL
+		return &DriverError{Kind: ErrNotNullViolation, Column: sqliteColumn(msg), Err: err}
+	}
+	switch v.FieldByName("Code").Int() {
+	case 5, 6: // SQLITE_BUSY, the busy timeout expired, and SQLITE_LOCKED, a conflicting lock of the shared cache
+		return &DriverError{Kind: ErrLockTimeout, Err: err}
+	}
+	return err
+}
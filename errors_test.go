@@ -0,0 +1,104 @@
+package dbx
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/mattn/go-sqlite3"
+)
+
+func TestDriverError_SQLite(t *testing.T) {
+	db := openSQLite(t)
+	db.MustExec("create unique index uniq_nickname on accounts(nickname)")
+	db.MustExec("create table notes(id integer primary key, body text not null)")
+
+	account := &accountRecord{NickName: sql.NullString{String: "jack", Valid: true}}
+	db.MustInsert(account)
+	_, err := db.Insert(&accountRecord{NickName: sql.NullString{String: "jack", Valid: true}})
+	if !IsUniqueViolation(err) {
+		t.Fatalf("expected unique violation, got:%v", err)
+	}
+	var driverErr *DriverError
+	if !errors.As(err, &driverErr) || driverErr.Column != "nickname" {
+		t.Fatalf("unexpected driver error:%#v", driverErr)
+	}
+
+	_, err = db.Exec("insert into notes(body) values(?)", nil)
+	if !IsNotNullViolation(err) || IsUniqueViolation(err) {
+		t.Fatalf("expected not null violation, got:%v", err)
+	}
+
+	err = db.Get(account, "select * from accounts where id=?", -1)
+	if !IsNotFound(err) || err != sql.ErrNoRows {
+		t.Fatalf("expected not found, got:%v", err)
+	}
+}
+
+func TestDialect_TranslateError(t *testing.T) {
+	cases := []struct {
+		err        error
+		kind       error
+		constraint string
+		column     string
+	}{
+		{&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'jack' for key 'accounts.uniq_nickname'"}, ErrUniqueViolation, "accounts.uniq_nickname", ""},
+		{&mysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row: a foreign key constraint fails (`test`.`accounts`, CONSTRAINT `fk_network` FOREIGN KEY (`network_id`) REFERENCES `networks` (`id`))"}, ErrForeignKeyViolation, "fk_network", "network_id"},
+		{&mysql.MySQLError{Number: 1048, Message: "Column 'nickname' cannot be null"}, ErrNotNullViolation, "", "nickname"},
+		{&mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}, ErrDeadlock, "", ""},
+		{&mysql.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded"}, ErrLockTimeout, "", ""},
+	}
+	option := &Options{}
+	for _, c := range cases {
+		err := option.translateError(c.err)
+		if !errors.Is(err, c.kind) || !errors.Is(err, c.err) {
+			t.Fatalf("%v is not %v", err, c.kind)
+		}
+		driverErr := err.(*DriverError)
+		if driverErr.Constraint != c.constraint || driverErr.Column != c.column {
+			t.Fatalf("unexpected driver error:%#v", driverErr)
+		}
+	}
+	for _, code := range []sqlite3.ErrNo{sqlite3.ErrBusy, sqlite3.ErrLocked} {
+		err := SQLite.TranslateError(sqlite3.Error{Code: code})
+		if !IsLockTimeout(err) || errors.Is(err, ErrDeadlock) {
+			t.Fatalf("sqlite code %d is %v", code, err)
+		}
+	}
+	unknown := &mysql.MySQLError{Number: 1146, Message: "Table 'test.missing' doesn't exist"}
+	if err := MySQL.TranslateError(unknown); err != unknown {
+		t.Fatalf("unexpected error:%v", err)
+	}
+}
+
+func TestQueryError(t *testing.T) {
+	db := openSQLite(t)
+	db.MustExec("create unique index uniq_nickname on accounts(nickname)")
+	db.MustInsert(&accountRecord{NickName: sql.NullString{String: "jack", Valid: true}})
+
+	_, err := db.Insert(&accountRecord{NickName: sql.NullString{String: "jack", Valid: true}})
+	var queryErr *QueryError
+	if !errors.As(err, &queryErr) {
+		t.Fatalf("expected query error, got:%v", err)
+	}
+	if queryErr.Op != OpExec || queryErr.Table != "accounts" || len(queryErr.Args) == 0 || !IsUniqueViolation(err) {
+		t.Fatalf("unexpected query error:%#v", queryErr)
+	}
+
+	var ids []int64
+	err = db.Query(&ids, "select x from accounts where id=?", 1)
+	if !errors.As(err, &queryErr) || queryErr.Op != OpPrepare {
+		t.Fatalf("expected prepare error, got:%v", err)
+	}
+	if err.Error() != "prepare: no such column: x (sql: select x from accounts where id=?, args: [1])" {
+		t.Fatalf("unexpected message:%v", err)
+	}
+
+	db.Options().RedactArgs = true
+	var id int64
+	err = db.Get(&id, "select id, nickname from accounts where id=?", 1)
+	if !errors.As(err, &queryErr) || queryErr.Op != OpQuery || queryErr.Args != nil {
+		t.Fatalf("expected redacted mapping error, got:%v", err)
+	}
+}
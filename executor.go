@@ -4,54 +4,59 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"reflect"
+	"sync/atomic"
+	"time"
 )
 
-var _ Executor = &executor{}
+var _ ExtendedExecutor = &executor{}
 
 type Executor interface {
 	// NamedExecContext executes a Named query without returning any rows.
-	// The arg are for any placeholder parameters in the query.
-	NamedExecContext(ctx context.Context, query string, arg map[string]interface{}) (sql.Result, error)
+	// The arg are for any placeholder parameters in the query, it is a map with string keys,
+	// a struct whose dbx columns are the parameter names, or a slice of them to insert multiple rows
+	// by repeating the VALUES list of the query.
+	NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error)
 
 	// NamedExec executes a Named query without returning any rows.
 	// The arg are for any placeholder parameters in the query.
-	NamedExec(query string, arg map[string]interface{}) (sql.Result, error)
+	NamedExec(query string, arg interface{}) (sql.Result, error)
 
 	// MustNamedExec is the same as  NamedExec but panics if cannot insert.
-	MustNamedExec(query string, arg map[string]interface{}) sql.Result
+	MustNamedExec(query string, arg interface{}) sql.Result
 
 	// NamedGetContext execute the Named query and scan the first row to dest, dest must be a pointer.
 	// if dest is a type supported by the database (string , int, []byte, time.Time, etc.)
 	// and there is only one column, the row will be assigned to dest.
 	// if dest is a struct, it will be mapped to the dbx tag field in the struct according to the name of each column.
 	// A sql.ErrNoRows is returned if the result set is empty.
-	NamedGetContext(ctx context.Context, dest interface{}, query string, arg map[string]interface{}) error
+	NamedGetContext(ctx context.Context, dest interface{}, query string, arg interface{}) error
 
 	// NamedGet execute the Named query and scan the first row to dest, dest must be a pointer.
 	// if dest is a type supported by the database (string , int, []byte, time.Time, etc.)
 	// and there is only one column, the row will be assigned to dest.
 	// if dest is a struct, it will be mapped to the dbx tag field in the struct according to the name of each column.
 	// A sql.ErrNoRows is returned if the result set is empty.
-	NamedGet(dest interface{}, query string, arg map[string]interface{}) error
+	NamedGet(dest interface{}, query string, arg interface{}) error
 
 	// MustNamedGet is the same as NamedGet, if there is a error in query, it will panics,
 	// but sql.ErrNoRows will return false
-	MustNamedGet(dest interface{}, query string, arg map[string]interface{}) bool
+	MustNamedGet(dest interface{}, query string, arg interface{}) bool
 
 	// NamedQueryContext execute the query and scan the rows to dest, dest must be a slice of pointer.
 	// if dest is a type supported by the database ([]string , []int, []byte, []time.Time, etc.)
 	// and there is only one column, the rows will be assigned to dest.
 	// if dest is a struct, it will be mapped to the dbx tag field in the struct according to the name of each column.
-	NamedQueryContext(ctx context.Context, dest interface{}, query string, arg map[string]interface{}) error
+	NamedQueryContext(ctx context.Context, dest interface{}, query string, arg interface{}) error
 
 	// NamedQuery execute the query and scan the rows to dest, dest must be a slice of pointer.
 	// if dest is a type supported by the database ([]string , []int, []byte, []time.Time, etc.)
 	// and there is only one column, the rows will be assigned to dest.
 	// if dest is a struct, it will be mapped to the dbx tag field in the struct according to the name of each column.
-	NamedQuery(dest interface{}, query string, arg map[string]interface{}) error
+	NamedQuery(dest interface{}, query string, arg interface{}) error
 
 	// MustNamedQuery like NamedQuery but panics if cannot query.
-	MustNamedQuery(dest interface{}, query string, arg map[string]interface{})
+	MustNamedQuery(dest interface{}, query string, arg interface{})
 
 	// PrepareContext creates a prepared statement
 	PrepareContext(ctx context.Context, query string) (*Stmt, error)
@@ -124,29 +129,64 @@ type Executor interface {
 	MustUpdate(value interface{}, columns ...string) (rs sql.Result)
 }
 
+// ExtendedExecutor is an Executor which also paginates, seeks, preloads relations, prepares named statements
+// and overrides the table of the structs. DB and Tx implement it, other implementations of Executor don't need to.
+type ExtendedExecutor interface {
+	Executor
+
+	// PrepareNamed creates a prepared named statement
+	PrepareNamed(ctx context.Context, query string) (*NamedStmt, error)
+
+	// Paginate scans the rows of the 1-based page to dest and returns the page with the total number of rows.
+	Paginate(ctx context.Context, dest interface{}, query string, page, size int, args ...interface{}) (Page, error)
+
+	// Seek scans a page of rows after or before the cursor of ks to dest, dest must be a pointer to a slice
+	// of structs implementing Table, and returns the cursors of the next and the previous pages.
+	Seek(ctx context.Context, dest interface{}, where string, ks Keyset, args ...interface{}) (KeysetPage, error)
+
+	// PreloadContext loads the has_many, has_one and belongs_to relations of dest,
+	// dest is a pointer to a struct or a slice of structs.
+	PreloadContext(ctx context.Context, dest interface{}, relations ...string) error
+
+	// Preload loads the has_many, has_one and belongs_to relations of dest,
+	// dest is a pointer to a struct or a slice of structs.
+	Preload(dest interface{}, relations ...string) error
+
+	// Table returns an ExtendedExecutor whose struct operations use the table name instead of the table of the struct.
+	Table(name string) ExtendedExecutor
+}
+
 // executor implemented SQLExecutor, NamedExecutor and StructExecutor
 type executor struct {
 	option   *Options
 	preparer preparer
+	inTx     bool
+	cache    *stmtCache
+	// replicas serve the reads of a cluster, it is nil for a single database and in a transaction.
+	replicas *replicaSet
+	// table overrides the table of the structs, see Table.
+	table string
 }
 
 func newDefaultExecutor(preparer preparer, option *Options) *executor {
-	return &executor{preparer: preparer, option: option}
+	return &executor{preparer: preparer, option: option, cache: newStmtCache()}
 }
 
 // InsertContext insert a struct to database
 func (e *executor) InsertContext(ctx context.Context, value interface{}) (rs sql.Result, err error) {
-	atv, query, values, err := e.option.Generator.InsertSQL(value)
+	table := e.structTable(ctx, value)
+	atv, query, values, err := e.insertSQL(table, value)
 	if err != nil {
 		return
 	}
+	ctx = withTable(ctx, table)
 	rs, err = e.ExecContext(ctx, query, values...)
 	if err != nil {
 		return rs, err
 	}
 	id, err := rs.LastInsertId()
 	if err != nil {
-		return nil, err
+		return nil, e.option.queryError(ctx, OpExec, query, values, err)
 	}
 	if atv != nil {
 		atv.SetInt(id)
@@ -171,11 +211,30 @@ func (e *executor) MustInsert(value interface{}) sql.Result {
 // UpdateContext update the rows according to the value of structure, if the column name is specified,
 // only the specified column is updated.
 func (e *executor) UpdateContext(ctx context.Context, value interface{}, columns ...string) (rs sql.Result, err error) {
-	query, values, err := e.option.Generator.UpdateSQL(value, columns...)
+	table := e.structTable(ctx, value)
+	query, values, err := e.updateSQL(table, value, columns...)
 	if err != nil {
 		return
 	}
-	return e.ExecContext(ctx, query, values...)
+	return e.ExecContext(withTable(ctx, table), query, values...)
+}
+
+// insertSQL generates the insert statement of value into table,
+// the static table of value is used if the Generator is not a TableSQLGenerator.
+func (e *executor) insertSQL(table string, value interface{}) (*reflect.Value, string, []interface{}, error) {
+	if g, ok := e.option.Generator.(TableSQLGenerator); ok && table != "" {
+		return g.InsertTableSQL(e.option.quoteTable(table), value)
+	}
+	return e.option.Generator.InsertSQL(value)
+}
+
+// updateSQL generates the update statement of value in table,
+// the static table of value is used if the Generator is not a TableSQLGenerator.
+func (e *executor) updateSQL(table string, value interface{}, columns ...string) (string, []interface{}, error) {
+	if g, ok := e.option.Generator.(TableSQLGenerator); ok && table != "" {
+		return g.UpdateTableSQL(e.option.quoteTable(table), value, columns...)
+	}
+	return e.option.Generator.UpdateSQL(value, columns...)
 }
 
 // Update the rows according to the value of structure, if the column name is specified,
@@ -195,12 +254,65 @@ func (e *executor) MustUpdate(value interface{}, columns ...string) (rs sql.Resu
 
 // Prepare creates a prepared statement
 func (e *executor) Prepare(query string) (*Stmt, error) {
-	return newStmt(e.preparer, query, e.option)
+	return e.PrepareContext(context.Background(), query)
 }
 
 // PrepareContext creates a prepared statement
 func (e *executor) PrepareContext(ctx context.Context, query string) (*Stmt, error) {
-	return newStmtContext(ctx, e.preparer, query, e.option)
+	stmt, err := newStmtContext(ctx, e.preparer, query, e.option, e.inTx)
+	if err != nil {
+		return nil, e.option.queryError(ctx, OpPrepare, query, nil, err)
+	}
+	return stmt, nil
+}
+
+// prepare returns a statement to execute query with args, the statement must be closed after use.
+// The statement comes from the cache of prepared statements, or is not prepared at all if Options.NoPrepare is set.
+// A failure is logged like a failed statement and returned as *QueryError.
+func (e *executor) prepare(ctx context.Context, query string, args []interface{}) (*Stmt, error) {
+	return e.prepareOn(ctx, e.preparer, e.cache, query, args)
+}
+
+// prepareOn is prepare on the database p whose prepared statements are cached in cache.
+func (e *executor) prepareOn(ctx context.Context, p preparer, cache *stmtCache, query string, args []interface{}) (*Stmt, error) {
+	if e.option.NoPrepare {
+		return &Stmt{rawQuery: query, option: e.option, inTx: e.inTx, direct: p}, nil
+	}
+	if cs := cache.get(query); cs != nil {
+		return &Stmt{rawQuery: query, stmt: cs.stmt, option: e.option, inTx: e.inTx, cached: cs, cache: cache}, nil
+	}
+	start := time.Now()
+	stmt, err := newStmtContext(ctx, p, query, e.option, e.inTx)
+	if err != nil {
+		logQuery(ctx, e.option, query, args, start, -1, err, e.inTx)
+		return nil, e.option.queryError(ctx, OpPrepare, query, args, err)
+	}
+	if cs := cache.put(query, stmt.stmt, e.option.StmtCacheSize); cs != nil {
+		stmt.cached, stmt.cache = cs, cache
+	}
+	return stmt, nil
+}
+
+// prepareRead is prepare for a read only query, the statement is prepared on a replica picked by
+// the balancer, or on the primary if there is no healthy replica or ctx is pinned by ForcePrimary.
+// done closes the statement and must be called after use.
+func (e *executor) prepareRead(ctx context.Context, query string, args []interface{}) (stmt *Stmt, done func(), err error) {
+	r := e.replicas.pick(ctx)
+	if r == nil {
+		if stmt, err = e.prepare(ctx, query, args); err != nil {
+			return nil, nil, err
+		}
+		return stmt, func() { _ = stmt.Close() }, nil
+	}
+	atomic.AddInt64(&r.inFlight, 1)
+	if stmt, err = e.prepareOn(ctx, r.db, r.cache, query, args); err != nil {
+		atomic.AddInt64(&r.inFlight, -1)
+		return nil, nil, err
+	}
+	return stmt, func() {
+		_ = stmt.Close()
+		atomic.AddInt64(&r.inFlight, -1)
+	}, nil
 }
 
 // GetContext execute the query and scan the first row to dest, dest must be a pointer.
@@ -208,15 +320,19 @@ func (e *executor) PrepareContext(ctx context.Context, query string) (*Stmt, err
 // and there is only one column, the row will be assigned to dest.
 // if dest is a struct, it will be mapped to the dbx tag field in the struct according to the name of each column.
 // An sql.ErrNoRows is returned if the result set is empty.
+// The relations of a Preload argument are loaded after scanning.
 func (e *executor) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) (err error) {
-	stmt, err := e.PrepareContext(ctx, query)
+	args, relations := splitPreload(args)
+	stmt, done, err := e.prepareRead(ctx, query, args)
 	if err != nil {
 		return
 	}
-	defer func() {
-		_ = stmt.Close()
-	}()
-	return stmt.GetContext(ctx, dest, args...)
+	err = stmt.GetContext(ctx, dest, args...)
+	done()
+	if err != nil || len(relations) == 0 {
+		return err
+	}
+	return e.PreloadContext(ctx, dest, relations...)
 }
 
 // Get execute the query and scan the first row to dest, dest must be a pointer.
@@ -245,15 +361,19 @@ func (e *executor) MustGet(dest interface{}, query string, args ...interface{})
 // if dest is a type supported by the database ([]string , []int, []byte, []time.Time, etc.)
 // and there is only one column, the rows will be assigned to dest.
 // if dest is a struct, it will be mapped to the dbx tag field in the struct according to the name of each column.
+// The relations of a Preload argument are loaded after scanning.
 func (e *executor) QueryContext(ctx context.Context, dest interface{}, query string, args ...interface{}) (err error) {
-	stmt, err := e.PrepareContext(ctx, query)
+	args, relations := splitPreload(args)
+	stmt, done, err := e.prepareRead(ctx, query, args)
 	if err != nil {
 		return
 	}
-	defer func() {
-		_ = stmt.Close()
-	}()
-	return stmt.QueryContext(ctx, dest, args...)
+	err = stmt.QueryContext(ctx, dest, args...)
+	done()
+	if err != nil || len(relations) == 0 {
+		return err
+	}
+	return e.PreloadContext(ctx, dest, relations...)
 }
 
 // Query execute the query and scan the rows to dest, dest must be a slice of pointer.
@@ -275,7 +395,7 @@ func (e *executor) MustQuery(dest interface{}, query string, args ...interface{}
 // ExecContext executes a query without returning any rows.
 // The args are for any placeholder parameters in the query.
 func (e *executor) ExecContext(ctx context.Context, query string, args ...interface{}) (rs sql.Result, err error) {
-	stmt, err := e.PrepareContext(ctx, query)
+	stmt, err := e.prepare(ctx, query, args)
 	if err != nil {
 		return
 	}
@@ -303,9 +423,11 @@ func (e *executor) MustExec(query string, args ...interface{}) (rs sql.Result) {
 }
 
 // NamedExecContext executes a Named query without returning any rows.
-// The arg are for any placeholder parameters in the query.
-func (e *executor) NamedExecContext(ctx context.Context, query string, arg map[string]interface{}) (sql.Result, error) {
-	query, args, err := namedCompile(query, arg)
+// The arg are for any placeholder parameters in the query, it is a map with string keys,
+// a struct whose dbx columns are the parameter names, or a slice of them to insert multiple rows
+// by repeating the VALUES list of the query.
+func (e *executor) NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error) {
+	query, args, err := namedCompileSyntax(query, arg, e.option.namedSyntax())
 	if err != nil {
 		return nil, err
 	}
@@ -314,12 +436,12 @@ func (e *executor) NamedExecContext(ctx context.Context, query string, arg map[s
 
 // NamedExec executes a Named query without returning any rows.
 // The arg are for any placeholder parameters in the query.
-func (e *executor) NamedExec(query string, arg map[string]interface{}) (sql.Result, error) {
+func (e *executor) NamedExec(query string, arg interface{}) (sql.Result, error) {
 	return e.NamedExecContext(context.Background(), query, arg)
 }
 
 // MustNamedExec is the same as  NamedExec but panics if cannot insert.
-func (e *executor) MustNamedExec(query string, arg map[string]interface{}) sql.Result {
+func (e *executor) MustNamedExec(query string, arg interface{}) sql.Result {
 	r, err := e.NamedExecContext(context.Background(), query, arg)
 	if err != nil {
 		panic(err)
@@ -332,8 +454,8 @@ func (e *executor) MustNamedExec(query string, arg map[string]interface{}) sql.R
 // and there is only one column, the row will be assigned to dest.
 // if dest is a struct, it will be mapped to the dbx tag field in the struct according to the name of each column.
 // A sql.ErrNoRows is returned if the result set is empty.
-func (e *executor) NamedGetContext(ctx context.Context, dest interface{}, query string, arg map[string]interface{}) (err error) {
-	query, args, err := namedCompile(query, arg)
+func (e *executor) NamedGetContext(ctx context.Context, dest interface{}, query string, arg interface{}) (err error) {
+	query, args, err := namedCompileSyntax(query, arg, e.option.namedSyntax())
 	if err != nil {
 		return
 	}
@@ -345,13 +467,13 @@ func (e *executor) NamedGetContext(ctx context.Context, dest interface{}, query
 // and there is only one column, the row will be assigned to dest.
 // if dest is a struct, it will be mapped to the dbx tag field in the struct according to the name of each column.
 // A sql.ErrNoRows is returned if the result set is empty.
-func (e *executor) NamedGet(dest interface{}, query string, arg map[string]interface{}) (err error) {
+func (e *executor) NamedGet(dest interface{}, query string, arg interface{}) (err error) {
 	return e.NamedGetContext(context.Background(), dest, query, arg)
 }
 
 // MustNamedGet is the same as NamedGet, if there is a error in query, it will panics,
 // but sql.ErrNoRows will return false
-func (e *executor) MustNamedGet(dest interface{}, query string, arg map[string]interface{}) bool {
+func (e *executor) MustNamedGet(dest interface{}, query string, arg interface{}) bool {
 	err := e.NamedGetContext(context.Background(), dest, query, arg)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -366,8 +488,8 @@ func (e *executor) MustNamedGet(dest interface{}, query string, arg map[string]i
 // if dest is a type supported by the database ([]string , []int, []byte, []time.Time, etc.)
 // and there is only one column, the rows will be assigned to dest.
 // if dest is a struct, it will be mapped to the dbx tag field in the struct according to the name of each column.
-func (e *executor) NamedQueryContext(ctx context.Context, dest interface{}, query string, arg map[string]interface{}) (err error) {
-	query, args, err := namedCompile(query, arg)
+func (e *executor) NamedQueryContext(ctx context.Context, dest interface{}, query string, arg interface{}) (err error) {
+	query, args, err := namedCompileSyntax(query, arg, e.option.namedSyntax())
 	if err != nil {
 		return
 	}
@@ -378,12 +500,12 @@ func (e *executor) NamedQueryContext(ctx context.Context, dest interface{}, quer
 // if dest is a type supported by the database ([]string , []int, []byte, []time.Time, etc.)
 // and there is only one column, the rows will be assigned to dest.
 // if dest is a struct, it will be mapped to the dbx tag field in the struct according to the name of each column.
-func (e *executor) NamedQuery(dest interface{}, query string, arg map[string]interface{}) (err error) {
+func (e *executor) NamedQuery(dest interface{}, query string, arg interface{}) (err error) {
 	return e.NamedQueryContext(context.Background(), dest, query, arg)
 }
 
 // MustNamedQuery like NamedQuery but panics if cannot query.
-func (e *executor) MustNamedQuery(dest interface{}, query string, arg map[string]interface{}) {
+func (e *executor) MustNamedQuery(dest interface{}, query string, arg interface{}) {
 	err := e.NamedQuery(dest, query, arg)
 	if err != nil {
 		panic(err)
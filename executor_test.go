@@ -4,13 +4,13 @@ import "context"
 
 
 
-func ExampleComplexExec_NamedExecContext() {
+func ExampleDB_NamedExecContext() {
 	_, err = db.NamedExecContext(context.Background(), "update account set name=:name", map[string]interface{}{
 		"name": "Lucy",
 	})
 }
 
-func ExampleComplexExecutor_NamedExec() {
+func ExampleDB_NamedExec() {
 	_, err = db.NamedExec("update account set name=:name", map[string]interface{}{
 		"name": "Lucy",
 	})
@@ -19,7 +19,7 @@ func ExampleComplexExecutor_NamedExec() {
 
 
 
-func ExampleComplexExec_NamedFind() {
+func ExampleDB_NamedQuery() {
 	account := &Account{}
 	err := db.NamedQuery(account, "select * from account where id in (:id)", map[string]interface{}{
 		"id": []int{1, 2, 3},
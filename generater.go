@@ -15,21 +15,39 @@ type SQLGenerator interface {
 	InsertSQL(value interface{}) (autoIncrement *reflect.Value, query string, args []interface{}, err error)
 }
 
+// TableSQLGenerator is a SQLGenerator generating the statements of a given table,
+// it is used instead of SQLGenerator when the table is resolved with TableContext, Options.TablePrefix, Table or WithTableName.
+// The table is quoted already.
+type TableSQLGenerator interface {
+	SQLGenerator
+	UpdateTableSQL(table string, value interface{}, columns ...string) (query string, args []interface{}, err error)
+	InsertTableSQL(table string, value interface{}) (autoIncrement *reflect.Value, query string, args []interface{}, err error)
+}
+
 // var tableInterfaceType = reflect.TypeOf(Table).Elem()
 func reflectTable(value interface{}) (tableName string, props reflectx.Properties, err error) {
-	v := reflect.ValueOf(value)
-	if v.Kind() != reflect.Ptr {
-		return "", nil, fmt.Errorf("value not a ptr")
-	}
-	direct := reflect.Indirect(v)
-	if !reflectx.IsStructValue(direct) {
-		return "", nil, fmt.Errorf("value not a struct")
+	props, err = reflectProperties(value)
+	if err != nil {
+		return "", nil, err
 	}
 	if tv, ok := value.(Table); ok {
 		tableName = tv.TableName()
 	} else {
 		return "", nil, errors.New("not implement generator.Table interface")
 	}
+	return tableName, props, nil
+}
+
+// reflectProperties returns the properties of the struct pointed by value sorted by column.
+func reflectProperties(value interface{}) (props reflectx.Properties, err error) {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Ptr {
+		return nil, fmt.Errorf("value not a ptr")
+	}
+	direct := reflect.Indirect(v)
+	if !reflectx.IsStructValue(direct) {
+		return nil, fmt.Errorf("value not a struct")
+	}
 
 	propertiesMap := map[string]reflectx.Property{}
 	reflectx.ReflectProperty(direct, propertiesMap)
@@ -42,7 +60,7 @@ func reflectTable(value interface{}) (tableName string, props reflectx.Propertie
 	}
 
 	sort.Sort(props)
-	return tableName, props, nil
+	return props, nil
 }
 
 type CommonSQLGenerator struct {
@@ -52,11 +70,24 @@ func NewCommonSQLGenerator() *CommonSQLGenerator {
 	return &CommonSQLGenerator{}
 }
 
-func (CommonSQLGenerator) UpdateSQL(value interface{}, columns ...string) (query string, args []interface{}, err error) {
+func (g CommonSQLGenerator) UpdateSQL(value interface{}, columns ...string) (query string, args []interface{}, err error) {
 	table, propsArr, err := reflectTable(value)
 	if err != nil {
 		return "", nil, err
 	}
+	return g.updateSQL(table, propsArr, columns...)
+}
+
+// UpdateTableSQL is UpdateSQL of the given table.
+func (g CommonSQLGenerator) UpdateTableSQL(table string, value interface{}, columns ...string) (query string, args []interface{}, err error) {
+	propsArr, err := reflectProperties(value)
+	if err != nil {
+		return "", nil, err
+	}
+	return g.updateSQL(table, propsArr, columns...)
+}
+
+func (CommonSQLGenerator) updateSQL(table string, propsArr reflectx.Properties, columns ...string) (query string, args []interface{}, err error) {
 	n := len(propsArr)
 	if n <= 0 {
 		return "", nil, fmt.Errorf("not found update columns")
@@ -110,11 +141,24 @@ func (CommonSQLGenerator) UpdateSQL(value interface{}, columns ...string) (query
 	return sql, values, nil
 }
 
-func (CommonSQLGenerator) InsertSQL(value interface{}) (autoIncrement *reflect.Value, query string, args []interface{}, err error) {
+func (g CommonSQLGenerator) InsertSQL(value interface{}) (autoIncrement *reflect.Value, query string, args []interface{}, err error) {
 	table, props, err := reflectTable(value)
 	if err != nil {
 		return nil, "", nil, err
 	}
+	return g.insertSQL(table, props)
+}
+
+// InsertTableSQL is InsertSQL of the given table.
+func (g CommonSQLGenerator) InsertTableSQL(table string, value interface{}) (autoIncrement *reflect.Value, query string, args []interface{}, err error) {
+	props, err := reflectProperties(value)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return g.insertSQL(table, props)
+}
+
+func (CommonSQLGenerator) insertSQL(table string, props reflectx.Properties) (autoIncrement *reflect.Value, query string, args []interface{}, err error) {
 	n := len(props)
 	if n <= 0 {
 		return nil, "", nil, fmt.Errorf("not found insert columns")
@@ -4,5 +4,7 @@ go 1.16
 
 require (
 	github.com/go-sql-driver/mysql v1.5.0
+	github.com/lib/pq v1.10.9
 	github.com/mattn/go-sqlite3 v1.14.16
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,123 @@
+package dbx
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Operation is the kind of operation passed to an Interceptor.
+type Operation string
+
+const (
+	OpPrepare  Operation = "prepare"
+	OpExec     Operation = "exec"
+	OpQuery    Operation = "query"
+	OpBegin    Operation = "begin"
+	OpCommit   Operation = "commit"
+	OpRollback Operation = "rollback"
+)
+
+// Invocation describes an operation intercepted by an Interceptor.
+type Invocation struct {
+	Op Operation
+	// SQL is the statement of prepare, exec and query operations.
+	SQL  string
+	Args []interface{}
+	// Table is the table name when the operation comes from a struct operation like Insert or Update.
+	Table string
+	InTx  bool
+	// RowsAffected is the number of rows affected by an exec or scanned by a query,
+	// it is available after the next Handler returns, -1 if it is unknown.
+	RowsAffected int64
+}
+
+// Handler executes an intercepted operation.
+type Handler func(ctx context.Context, inv *Invocation) error
+
+// Interceptor wraps every operation, it must call next to execute the operation.
+// The ctx passed to next is used by the operation, so an interceptor can attach values like spans to it.
+type Interceptor func(ctx context.Context, inv *Invocation, next Handler) error
+
+// intercept calls fn through the Interceptors of options, the first interceptor is the outermost.
+func (o *Options) intercept(ctx context.Context, inv *Invocation, fn Handler) error {
+	h := fn
+	for i := len(o.Interceptors) - 1; i >= 0; i-- {
+		interceptor, next := o.Interceptors[i], h
+		h = func(ctx context.Context, inv *Invocation) error {
+			return interceptor(ctx, inv, next)
+		}
+	}
+	return h(ctx, inv)
+}
+
+type tableContextKey struct{}
+
+// withTable returns a copy of ctx carrying the table name of a struct operation.
+func withTable(ctx context.Context, table string) context.Context {
+	return context.WithValue(ctx, tableContextKey{}, table)
+}
+
+func tableFromContext(ctx context.Context) string {
+	table, _ := ctx.Value(tableContextKey{}).(string)
+	return table
+}
+
+// Record is an operation recorded by a Recorder.
+type Record struct {
+	Op           Operation
+	SQL          string
+	Args         []interface{}
+	Table        string
+	InTx         bool
+	RowsAffected int64
+	Duration     time.Duration
+	Err          error
+}
+
+// Recorder is an Interceptor keeping every operation in memory, it is meant for tests.
+//
+//	rec := dbx.NewRecorder()
+//	db.Options().Interceptors = append(db.Options().Interceptors, rec.Intercept)
+type Recorder struct {
+	mu      sync.Mutex
+	records []Record
+}
+
+// NewRecorder returns an empty Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Intercept executes the operation and records it, it is an Interceptor.
+func (r *Recorder) Intercept(ctx context.Context, inv *Invocation, next Handler) error {
+	start := time.Now()
+	err := next(ctx, inv)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records = append(r.records, Record{
+		Op:           inv.Op,
+		SQL:          inv.SQL,
+		Args:         inv.Args,
+		Table:        inv.Table,
+		InTx:         inv.InTx,
+		RowsAffected: inv.RowsAffected,
+		Duration:     time.Since(start),
+		Err:          err,
+	})
+	return err
+}
+
+// Records returns a copy of the recorded operations.
+func (r *Recorder) Records() []Record {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Record(nil), r.records...)
+}
+
+// Reset forgets all recorded operations.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records = nil
+}
@@ -0,0 +1,95 @@
+package dbx
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRecorder(t *testing.T) {
+	db := openSQLite(t)
+	rec := NewRecorder()
+	db.Options().Interceptors = []Interceptor{rec.Intercept}
+
+	err := db.Transaction(func(tx *Tx) error {
+		_, err := tx.Insert(&accountRecord{Status: 1})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int64
+	db.MustQuery(&ids, "select id from accounts")
+
+	records := rec.Records()
+	ops := []Operation{OpBegin, OpPrepare, OpExec, OpCommit, OpPrepare, OpQuery}
+	if len(records) != len(ops) {
+		t.Fatalf("unexpected records:%+v", records)
+	}
+	for i, op := range ops {
+		if records[i].Op != op {
+			t.Fatalf("records[%d] is %s, expected %s", i, records[i].Op, op)
+		}
+	}
+	if records[2].Table != "accounts" || !records[2].InTx || records[2].RowsAffected != 1 {
+		t.Fatalf("unexpected exec record:%+v", records[2])
+	}
+	if records[5].Table != "" || records[5].InTx || records[5].RowsAffected != 1 {
+		t.Fatalf("unexpected query record:%+v", records[5])
+	}
+}
+
+type contextKey string
+
+func TestOptions_Interceptors(t *testing.T) {
+	db := openSQLite(t)
+	var order []string
+	var values []interface{}
+	db.Options().Interceptors = []Interceptor{
+		func(ctx context.Context, inv *Invocation, next Handler) error {
+			order = append(order, "outer")
+			return next(context.WithValue(ctx, contextKey("span"), inv.Op), inv)
+		},
+		func(ctx context.Context, inv *Invocation, next Handler) error {
+			order = append(order, "inner")
+			values = append(values, ctx.Value(contextKey("span")))
+			return next(ctx, inv)
+		},
+	}
+	db.MustExec("update accounts set status=?", 1)
+	if len(order) != 4 || order[0] != "outer" || order[1] != "inner" {
+		t.Fatalf("unexpected order:%v", order)
+	}
+	if values[0] != OpPrepare || values[1] != OpExec {
+		t.Fatalf("unexpected context values:%v", values)
+	}
+}
+
+func TestOptions_Interceptors_RejectCommit(t *testing.T) {
+	db := openSQLite(t)
+	db.RawDB().SetMaxOpenConns(1)
+	errRejected := errors.New("rejected")
+	db.Options().Interceptors = []Interceptor{func(ctx context.Context, inv *Invocation, next Handler) error {
+		if inv.Op == OpCommit {
+			return errRejected
+		}
+		return next(ctx, inv)
+	}}
+	err := db.Transaction(func(tx *Tx) error {
+		_, err := tx.Insert(&accountRecord{Status: 1})
+		return err
+	})
+	if err != errRejected {
+		t.Fatalf("expected the interceptor error, got:%v", err)
+	}
+	if inUse := db.RawDB().Stats().InUse; inUse != 0 {
+		t.Fatalf("the connection of the rejected transaction is in use:%d", inUse)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	var n int
+	if err = db.GetContext(ctx, &n, "select count(*) from accounts"); err != nil || n != 0 {
+		t.Fatalf("unexpected count:%d err:%v", n, err)
+	}
+}
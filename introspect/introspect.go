@@ -0,0 +1,175 @@
+// Package introspect reads the schema of a MySQL, PostgreSQL or SQLite database: its tables with their columns,
+// primary key, indexes and foreign keys, and its views.
+// It only depends on database/sql, so it is usable with a *sql.DB, a *sql.Conn or a *sql.Tx.
+package introspect
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// The driver names of the supported databases.
+const (
+	MySQL    = "mysql"
+	Postgres = "postgres"
+	SQLite   = "sqlite3"
+)
+
+// Queryer executes the introspection queries, it is implemented by *sql.DB, *sql.Conn and *sql.Tx.
+type Queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Schema is the tables and the views of a database schema.
+type Schema struct {
+	Name   string
+	Tables []*Table
+	Views  []*View
+}
+
+// Table returns the table name, nil if there is no such table.
+func (s *Schema) Table(name string) *Table {
+	for _, t := range s.Tables {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// Table is a table of a schema.
+type Table struct {
+	Name    string
+	Comment string
+	// Columns are ordered by position.
+	Columns []*Column
+	// PrimaryKey is the columns of the primary key, empty if there is none.
+	PrimaryKey []string
+	// Indexes are the indexes other than the primary key ordered by name.
+	Indexes     []*Index
+	ForeignKeys []*ForeignKey
+}
+
+// Column returns the column name, nil if there is no such column.
+func (t *Table) Column(name string) *Column {
+	for _, c := range t.Columns {
+		if strings.EqualFold(c.Name, name) {
+			return c
+		}
+	}
+	return nil
+}
+
+// Column is a column of a table.
+type Column struct {
+	Name string
+	// Position is the 1-based position of the column in the table.
+	Position int
+	// DataType is the lower case type name without length or modifiers, like varchar.
+	// It is the udt_name for PostgreSQL, like int4 or timestamptz, followed by [] for the arrays.
+	DataType string
+	// ColumnType is the full type, like varchar(255) or int unsigned for MySQL, character varying(255) or integer[]
+	// for PostgreSQL and the declared type for SQLite.
+	ColumnType string
+	// Nullable is false for the NOT NULL columns. A PRIMARY KEY of SQLite which is not declared NOT NULL
+	// is reported as nullable, SQLite accepts NULL in it unless it is the rowid.
+	Nullable bool
+	// Default is the DEFAULT expression, nil if the column has no default.
+	Default *string
+	// PrimaryKey is set when the column is part of the primary key.
+	PrimaryKey bool
+	// AutoIncrement is set for the auto_increment columns of MySQL, the identity and serial columns of PostgreSQL
+	// and the columns of SQLite which are an alias of the rowid, with or without the AUTOINCREMENT keyword.
+	AutoIncrement bool
+	Comment       string
+}
+
+// Index is an index of a table.
+type Index struct {
+	Name    string
+	Unique  bool
+	Columns []string
+}
+
+// ForeignKey is a foreign key of a table.
+type ForeignKey struct {
+	// Name is the name of the constraint, it is empty for SQLite.
+	Name     string
+	Columns  []string
+	RefTable string
+	// RefColumns are empty names for SQLite when the REFERENCES clause refers to the primary key implicitly.
+	RefColumns []string
+	OnUpdate   string
+	OnDelete   string
+}
+
+// View is a view of a schema.
+type View struct {
+	Name string
+	// Definition is the SELECT statement of the view for MySQL and PostgreSQL and the CREATE VIEW statement for SQLite.
+	Definition string
+}
+
+// Load reads the schema of the database of driver, the current database if schema is empty.
+func Load(ctx context.Context, q Queryer, driver string, schema string) (*Schema, error) {
+	switch strings.ToLower(driver) {
+	case MySQL:
+		return loadMySQL(ctx, q, schema, "")
+	case Postgres:
+		return loadPostgres(ctx, q, schema, "")
+	case SQLite:
+		return loadSQLite(ctx, q, schema, "")
+	}
+	return nil, fmt.Errorf("introspect: unsupported driver %s", driver)
+}
+
+// LoadTable reads a single table, nil if the table doesn't exist.
+// The table may be qualified by a schema like schema.table.
+func LoadTable(ctx context.Context, q Queryer, driver string, table string) (*Table, error) {
+	schema, name := "", table
+	if i := strings.LastIndex(table, "."); i >= 0 {
+		schema, name = table[:i], table[i+1:]
+	}
+	var s *Schema
+	var err error
+	switch strings.ToLower(driver) {
+	case MySQL:
+		s, err = loadMySQL(ctx, q, schema, name)
+	case Postgres:
+		s, err = loadPostgres(ctx, q, schema, name)
+	case SQLite:
+		s, err = loadSQLite(ctx, q, schema, name)
+	default:
+		return nil, fmt.Errorf("introspect: unsupported driver %s", driver)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.Table(name), nil
+}
+
+// query scans every row of the query with scan.
+func query(ctx context.Context, q Queryer, scan func(rows *sql.Rows) error, query string, args ...interface{}) error {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err = scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// dataType returns the lower case type name of a column type, varchar of varchar(255) or int of int unsigned.
+func dataType(columnType string) string {
+	t := strings.ToLower(strings.TrimSpace(columnType))
+	if i := strings.IndexAny(t, "( "); i >= 0 {
+		t = t[:i]
+	}
+	return t
+}
@@ -0,0 +1,161 @@
+package introspect
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestLoad_SQLite(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "introspect.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, ddl := range []string{
+		"create table authors (id integer primary key autoincrement, name varchar(64) not null default 'anonymous')",
+		"create table books (id INTEGER primary key, author_id int not null references authors(id) on delete cascade, title text, isbn char(13))",
+		"create unique index uk_books_isbn on books(isbn)",
+		"create index idx_books_author_title on books(author_id, title)",
+		"create table tags (name text primary key, weight bigint)",
+		"create table rowless (id integer primary key, value text) without rowid",
+		"create table book_tags (book_id integer, tag text, primary key (book_id, tag))",
+		"create view author_books as select a.name, b.title from authors a join books b on b.author_id = a.id",
+	} {
+		if _, err = db.Exec(ddl); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, err := Load(ctx, db, "sqlite3", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, table := range s.Tables {
+		names = append(names, table.Name)
+	}
+	if want := []string{"authors", "book_tags", "books", "rowless", "tags"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("tables=%v, want %v", names, want)
+	}
+	if len(s.Views) != 1 || s.Views[0].Name != "author_books" {
+		t.Fatalf("views=%+v", s.Views)
+	}
+
+	autoIncrement := map[string]bool{
+		"authors": true, "books": true, "tags": false, "rowless": false, "book_tags": false,
+	}
+	for table, want := range autoIncrement {
+		if got := s.Table(table).Columns[0].AutoIncrement; got != want {
+			t.Errorf("%s auto increment=%v, want %v", table, got, want)
+		}
+	}
+
+	authors := s.Table("authors")
+	name := authors.Column("name")
+	if name.Nullable || name.DataType != "varchar" || name.ColumnType != "varchar(64)" || name.Default == nil || *name.Default != "'anonymous'" || name.Position != 2 {
+		t.Errorf("name=%+v", name)
+	}
+
+	books := s.Table("books")
+	if !reflect.DeepEqual(books.PrimaryKey, []string{"id"}) {
+		t.Errorf("primary key=%v", books.PrimaryKey)
+	}
+	wantIndexes := []*Index{
+		{Name: "idx_books_author_title", Columns: []string{"author_id", "title"}},
+		{Name: "uk_books_isbn", Unique: true, Columns: []string{"isbn"}},
+	}
+	if !reflect.DeepEqual(books.Indexes, wantIndexes) {
+		t.Errorf("indexes=%+v", books.Indexes)
+	}
+	wantFK := []*ForeignKey{{Columns: []string{"author_id"}, RefTable: "authors", RefColumns: []string{"id"}, OnUpdate: "NO ACTION", OnDelete: "CASCADE"}}
+	if !reflect.DeepEqual(books.ForeignKeys, wantFK) {
+		t.Errorf("foreign keys=%+v", books.ForeignKeys[0])
+	}
+
+	if pk := s.Table("book_tags").PrimaryKey; !reflect.DeepEqual(pk, []string{"book_id", "tag"}) {
+		t.Errorf("composite primary key=%v", pk)
+	}
+	if indexes := s.Table("tags").Indexes; len(indexes) != 0 {
+		t.Errorf("the index of the primary key is reported: %+v", indexes[0])
+	}
+
+	table, err := LoadTable(ctx, db, "sqlite3", "main.books")
+	if err != nil || !reflect.DeepEqual(table, books) {
+		t.Errorf("LoadTable=%+v err=%v", table, err)
+	}
+	if table, err = LoadTable(ctx, db, "sqlite3", "missing"); table != nil || err != nil {
+		t.Errorf("LoadTable(missing)=%+v err=%v", table, err)
+	}
+	if _, err = Load(ctx, db, "oracle", ""); err == nil {
+		t.Error("expected an error for an unsupported driver")
+	}
+}
+
+func Test_buildPostgres(t *testing.T) {
+	nextval := "nextval('orders_id_seq'::regclass)"
+	s := buildPostgres("public",
+		[]pgRelation{
+			{name: "customers", kind: "r"},
+			{name: "orders", kind: "r", comment: "customer orders"},
+			{name: "recent_orders", kind: "v", definition: " SELECT orders.id FROM orders;"},
+		},
+		[]pgColumn{
+			{table: "customers", name: "id", position: 1, udtName: "int8", columnType: "bigint", nullable: "NO", identity: "YES"},
+			{table: "customers", name: "uid", position: 2, udtName: "uuid", columnType: "uuid", nullable: "NO"},
+			{table: "orders", name: "id", position: 1, udtName: "int4", columnType: "integer", nullable: "NO", def: sql.NullString{String: nextval, Valid: true}},
+			{table: "orders", name: "customer_id", position: 2, udtName: "int8", columnType: "bigint", nullable: "NO"},
+			{table: "orders", name: "tags", position: 3, udtName: "_text", columnType: "text[]", nullable: "YES"},
+			{table: "orders", name: "amount", position: 4, udtName: "numeric", columnType: "numeric(10,2)", nullable: "YES", comment: "total"},
+		},
+		[]pgIndexColumn{
+			{table: "customers", index: "customers_pkey", unique: true, primary: true, column: "id"},
+			{table: "customers", index: "customers_uid_key", unique: true, column: "uid"},
+			{table: "orders", index: "orders_pkey", unique: true, primary: true, column: "id"},
+			{table: "orders", index: "orders_customer_amount", column: "customer_id"},
+			{table: "orders", index: "orders_customer_amount", column: "amount"},
+		},
+		[]pgForeignKeyColumn{
+			{table: "orders", name: "orders_customer_id_fkey", column: "customer_id", refTable: "customers", refColumn: "id", onUpdate: "a", onDelete: "c"},
+		},
+	)
+	if len(s.Tables) != 2 || len(s.Views) != 1 || s.Views[0].Name != "recent_orders" {
+		t.Fatalf("tables=%d views=%+v", len(s.Tables), s.Views)
+	}
+	customers, orders := s.Table("customers"), s.Table("orders")
+	if c := customers.Column("id"); !c.AutoIncrement || !c.PrimaryKey || c.DataType != "int8" {
+		t.Errorf("identity column=%+v", c)
+	}
+	if c := customers.Column("uid"); c.AutoIncrement || c.PrimaryKey {
+		t.Errorf("uid=%+v", c)
+	}
+	if c := orders.Column("id"); !c.AutoIncrement || *c.Default != nextval {
+		t.Errorf("serial column=%+v", c)
+	}
+	if c := orders.Column("tags"); c.DataType != "text[]" || !c.Nullable {
+		t.Errorf("array column=%+v", c)
+	}
+	if orders.Comment != "customer orders" || orders.Column("amount").Comment != "total" {
+		t.Errorf("comments=%q %q", orders.Comment, orders.Column("amount").Comment)
+	}
+	if !reflect.DeepEqual(orders.PrimaryKey, []string{"id"}) {
+		t.Errorf("primary key=%v", orders.PrimaryKey)
+	}
+	wantIndexes := []*Index{{Name: "orders_customer_amount", Columns: []string{"customer_id", "amount"}}}
+	if !reflect.DeepEqual(orders.Indexes, wantIndexes) {
+		t.Errorf("indexes=%+v", orders.Indexes)
+	}
+	if len(customers.Indexes) != 1 || !customers.Indexes[0].Unique {
+		t.Errorf("unique indexes=%+v", customers.Indexes)
+	}
+	wantFK := []*ForeignKey{{Name: "orders_customer_id_fkey", Columns: []string{"customer_id"}, RefTable: "customers",
+		RefColumns: []string{"id"}, OnUpdate: "NO ACTION", OnDelete: "CASCADE"}}
+	if !reflect.DeepEqual(orders.ForeignKeys, wantFK) {
+		t.Errorf("foreign keys=%+v", orders.ForeignKeys[0])
+	}
+}
@@ -0,0 +1,131 @@
+package introspect
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+// loadMySQL reads the tables and the views of schema from the information_schema, only table when it is not empty.
+func loadMySQL(ctx context.Context, q Queryer, schema string, table string) (*Schema, error) {
+	s := &Schema{Name: schema}
+	tables := map[string]*Table{}
+	const where = "table_schema = coalesce(nullif(?, ''), database()) and (? = '' or table_name = ?)"
+	err := query(ctx, q, func(rows *sql.Rows) error {
+		var typ string
+		t := &Table{}
+		if err := rows.Scan(&t.Name, &typ, &t.Comment); err != nil {
+			return err
+		}
+		if typ == "VIEW" {
+			return nil
+		}
+		tables[t.Name] = t
+		s.Tables = append(s.Tables, t)
+		return nil
+	}, "select table_name, table_type, coalesce(table_comment, '') from information_schema.tables where "+where+" order by table_name",
+		schema, table, table)
+	if err != nil {
+		return nil, err
+	}
+
+	err = query(ctx, q, func(rows *sql.Rows) error {
+		var name, nullable, key, extra string
+		c := &Column{}
+		var def sql.NullString
+		if err := rows.Scan(&name, &c.Name, &c.Position, &c.ColumnType, &nullable, &def, &key, &extra, &c.Comment); err != nil {
+			return err
+		}
+		t := tables[name]
+		if t == nil {
+			return nil
+		}
+		c.DataType = dataType(c.ColumnType)
+		c.Nullable = nullable == "YES"
+		if def.Valid {
+			c.Default = &def.String
+		}
+		c.PrimaryKey = key == "PRI"
+		c.AutoIncrement = strings.Contains(strings.ToLower(extra), "auto_increment")
+		t.Columns = append(t.Columns, c)
+		return nil
+	}, `select table_name, column_name, ordinal_position, column_type, is_nullable, column_default,
+			column_key, extra, coalesce(column_comment, '')
+		from information_schema.columns where `+where+` order by table_name, ordinal_position`,
+		schema, table, table)
+	if err != nil {
+		return nil, err
+	}
+
+	err = query(ctx, q, func(rows *sql.Rows) error {
+		var name, index, column string
+		var nonUnique int
+		if err := rows.Scan(&name, &index, &nonUnique, &column); err != nil {
+			return err
+		}
+		t := tables[name]
+		if t == nil {
+			return nil
+		}
+		if index == "PRIMARY" {
+			t.PrimaryKey = append(t.PrimaryKey, column)
+			return nil
+		}
+		n := len(t.Indexes)
+		if n == 0 || t.Indexes[n-1].Name != index {
+			t.Indexes = append(t.Indexes, &Index{Name: index, Unique: nonUnique == 0})
+			n++
+		}
+		t.Indexes[n-1].Columns = append(t.Indexes[n-1].Columns, column)
+		return nil
+	}, "select table_name, index_name, non_unique, column_name from information_schema.statistics where "+where+
+		" order by table_name, index_name, seq_in_index", schema, table, table)
+	if err != nil {
+		return nil, err
+	}
+
+	err = query(ctx, q, func(rows *sql.Rows) error {
+		var name, constraint, column, refTable, refColumn, onUpdate, onDelete string
+		if err := rows.Scan(&name, &constraint, &column, &refTable, &refColumn, &onUpdate, &onDelete); err != nil {
+			return err
+		}
+		t := tables[name]
+		if t == nil {
+			return nil
+		}
+		n := len(t.ForeignKeys)
+		if n == 0 || t.ForeignKeys[n-1].Name != constraint {
+			t.ForeignKeys = append(t.ForeignKeys, &ForeignKey{Name: constraint, RefTable: refTable, OnUpdate: onUpdate, OnDelete: onDelete})
+			n++
+		}
+		fk := t.ForeignKeys[n-1]
+		fk.Columns = append(fk.Columns, column)
+		fk.RefColumns = append(fk.RefColumns, refColumn)
+		return nil
+	}, `select k.table_name, k.constraint_name, k.column_name, k.referenced_table_name, k.referenced_column_name,
+			r.update_rule, r.delete_rule
+		from information_schema.key_column_usage k
+		join information_schema.referential_constraints r
+			on r.constraint_schema = k.constraint_schema and r.constraint_name = k.constraint_name and r.table_name = k.table_name
+		where k.table_schema = coalesce(nullif(?, ''), database()) and (? = '' or k.table_name = ?)
+		order by k.table_name, k.constraint_name, k.ordinal_position`, schema, table, table)
+	if err != nil {
+		return nil, err
+	}
+
+	if table != "" {
+		return s, nil
+	}
+	err = query(ctx, q, func(rows *sql.Rows) error {
+		v := &View{}
+		if err := rows.Scan(&v.Name, &v.Definition); err != nil {
+			return err
+		}
+		s.Views = append(s.Views, v)
+		return nil
+	}, "select table_name, view_definition from information_schema.views where table_schema = coalesce(nullif(?, ''), database()) order by table_name", schema)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
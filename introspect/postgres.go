@@ -0,0 +1,222 @@
+package introspect
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+// pgRelation is a row of pg_class, a table or a view.
+type pgRelation struct {
+	name       string
+	kind       string
+	comment    string
+	definition string
+}
+
+// pgColumn is a row of information_schema.columns.
+type pgColumn struct {
+	table      string
+	name       string
+	position   int
+	udtName    string
+	columnType string
+	nullable   string
+	def        sql.NullString
+	identity   string
+	comment    string
+}
+
+// pgIndexColumn is a column of a row of pg_index.
+type pgIndexColumn struct {
+	table   string
+	index   string
+	unique  bool
+	primary bool
+	column  string
+}
+
+// pgForeignKeyColumn is a column of a foreign key of pg_constraint.
+type pgForeignKeyColumn struct {
+	table     string
+	name      string
+	column    string
+	refTable  string
+	refColumn string
+	onUpdate  string
+	onDelete  string
+}
+
+// pgActions are the referential actions of pg_constraint.confupdtype and confdeltype.
+var pgActions = map[string]string{
+	"a": "NO ACTION",
+	"r": "RESTRICT",
+	"c": "CASCADE",
+	"n": "SET NULL",
+	"d": "SET DEFAULT",
+}
+
+// loadPostgres reads the tables and the views of schema from the information_schema and the pg_catalog,
+// only table when it is not empty. The schema defaults to current_schema().
+func loadPostgres(ctx context.Context, q Queryer, schema string, table string) (*Schema, error) {
+	var relations []pgRelation
+	err := query(ctx, q, func(rows *sql.Rows) error {
+		var r pgRelation
+		if err := rows.Scan(&r.name, &r.kind, &r.comment, &r.definition); err != nil {
+			return err
+		}
+		relations = append(relations, r)
+		return nil
+	}, `select c.relname, c.relkind::text, coalesce(obj_description(c.oid, 'pg_class'), ''),
+			case when c.relkind = 'v' then pg_get_viewdef(c.oid) else '' end
+		from pg_class c join pg_namespace n on n.oid = c.relnamespace
+		where n.nspname = coalesce(nullif($1, ''), current_schema()) and c.relkind in ('r', 'p', 'v') and ($2 = '' or c.relname = $2)
+		order by c.relname`, schema, table)
+	if err != nil {
+		return nil, err
+	}
+
+	var columns []pgColumn
+	err = query(ctx, q, func(rows *sql.Rows) error {
+		var c pgColumn
+		if err := rows.Scan(&c.table, &c.name, &c.position, &c.udtName, &c.columnType, &c.nullable, &c.def, &c.identity, &c.comment); err != nil {
+			return err
+		}
+		columns = append(columns, c)
+		return nil
+	}, `select c.table_name, c.column_name, c.ordinal_position, c.udt_name, format_type(a.atttypid, a.atttypmod),
+			c.is_nullable, c.column_default, coalesce(c.is_identity, 'NO'), coalesce(col_description(a.attrelid, a.attnum), '')
+		from information_schema.columns c
+		join pg_attribute a on a.attrelid = format('%I.%I', c.table_schema, c.table_name)::regclass and a.attname = c.column_name
+		where c.table_schema = coalesce(nullif($1, ''), current_schema()) and ($2 = '' or c.table_name = $2)
+		order by c.table_name, c.ordinal_position`, schema, table)
+	if err != nil {
+		return nil, err
+	}
+
+	var indexColumns []pgIndexColumn
+	err = query(ctx, q, func(rows *sql.Rows) error {
+		var c pgIndexColumn
+		if err := rows.Scan(&c.table, &c.index, &c.unique, &c.primary, &c.column); err != nil {
+			return err
+		}
+		indexColumns = append(indexColumns, c)
+		return nil
+	}, `select t.relname, i.relname, ix.indisunique, ix.indisprimary, a.attname
+		from pg_index ix
+		join pg_class t on t.oid = ix.indrelid
+		join pg_class i on i.oid = ix.indexrelid
+		join pg_namespace n on n.oid = t.relnamespace
+		join lateral unnest(ix.indkey) with ordinality k(attnum, ord) on true
+		join pg_attribute a on a.attrelid = t.oid and a.attnum = k.attnum
+		where n.nspname = coalesce(nullif($1, ''), current_schema()) and ($2 = '' or t.relname = $2)
+		order by t.relname, i.relname, k.ord`, schema, table)
+	if err != nil {
+		return nil, err
+	}
+
+	var foreignKeys []pgForeignKeyColumn
+	err = query(ctx, q, func(rows *sql.Rows) error {
+		var c pgForeignKeyColumn
+		if err := rows.Scan(&c.table, &c.name, &c.column, &c.refTable, &c.refColumn, &c.onUpdate, &c.onDelete); err != nil {
+			return err
+		}
+		foreignKeys = append(foreignKeys, c)
+		return nil
+	}, `select t.relname, con.conname, a.attname, r.relname, ra.attname, con.confupdtype::text, con.confdeltype::text
+		from pg_constraint con
+		join pg_class t on t.oid = con.conrelid
+		join pg_namespace n on n.oid = t.relnamespace
+		join pg_class r on r.oid = con.confrelid
+		join lateral unnest(con.conkey, con.confkey) with ordinality k(col, refcol, ord) on true
+		join pg_attribute a on a.attrelid = con.conrelid and a.attnum = k.col
+		join pg_attribute ra on ra.attrelid = con.confrelid and ra.attnum = k.refcol
+		where con.contype = 'f' and n.nspname = coalesce(nullif($1, ''), current_schema()) and ($2 = '' or t.relname = $2)
+		order by t.relname, con.conname, k.ord`, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	return buildPostgres(schema, relations, columns, indexColumns, foreignKeys), nil
+}
+
+// buildPostgres assembles the rows read from the catalog by loadPostgres.
+func buildPostgres(schema string, relations []pgRelation, columns []pgColumn, indexColumns []pgIndexColumn, foreignKeys []pgForeignKeyColumn) *Schema {
+	s := &Schema{Name: schema}
+	tables := map[string]*Table{}
+	for _, r := range relations {
+		if r.kind == "v" {
+			s.Views = append(s.Views, &View{Name: r.name, Definition: r.definition})
+			continue
+		}
+		t := &Table{Name: r.name, Comment: r.comment}
+		tables[t.Name] = t
+		s.Tables = append(s.Tables, t)
+	}
+
+	for _, pc := range columns {
+		t := tables[pc.table]
+		if t == nil {
+			continue
+		}
+		c := &Column{
+			Name:       pc.name,
+			Position:   pc.position,
+			DataType:   pgDataType(pc.udtName),
+			ColumnType: pc.columnType,
+			Nullable:   pc.nullable == "YES",
+			Comment:    pc.comment,
+		}
+		if pc.def.Valid {
+			def := pc.def.String
+			c.Default = &def
+		}
+		// serial and bigserial are integers whose default is the next value of their sequence.
+		c.AutoIncrement = pc.identity == "YES" || pc.def.Valid && strings.HasPrefix(pc.def.String, "nextval(")
+		t.Columns = append(t.Columns, c)
+	}
+
+	for _, ic := range indexColumns {
+		t := tables[ic.table]
+		if t == nil {
+			continue
+		}
+		if ic.primary {
+			t.PrimaryKey = append(t.PrimaryKey, ic.column)
+			if c := t.Column(ic.column); c != nil {
+				c.PrimaryKey = true
+			}
+			continue
+		}
+		n := len(t.Indexes)
+		if n == 0 || t.Indexes[n-1].Name != ic.index {
+			t.Indexes = append(t.Indexes, &Index{Name: ic.index, Unique: ic.unique})
+			n++
+		}
+		t.Indexes[n-1].Columns = append(t.Indexes[n-1].Columns, ic.column)
+	}
+
+	for _, fc := range foreignKeys {
+		t := tables[fc.table]
+		if t == nil {
+			continue
+		}
+		n := len(t.ForeignKeys)
+		if n == 0 || t.ForeignKeys[n-1].Name != fc.name {
+			t.ForeignKeys = append(t.ForeignKeys, &ForeignKey{Name: fc.name, RefTable: fc.refTable,
+				OnUpdate: pgActions[fc.onUpdate], OnDelete: pgActions[fc.onDelete]})
+			n++
+		}
+		fk := t.ForeignKeys[n-1]
+		fk.Columns = append(fk.Columns, fc.column)
+		fk.RefColumns = append(fk.RefColumns, fc.refColumn)
+	}
+	return s
+}
+
+// pgDataType returns the type name of udt_name, the array types like _int4 are returned as int4[].
+func pgDataType(udtName string) string {
+	if strings.HasPrefix(udtName, "_") {
+		return udtName[1:] + "[]"
+	}
+	return udtName
+}
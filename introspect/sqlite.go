@@ -0,0 +1,131 @@
+package introspect
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var withoutRowid = regexp.MustCompile(`(?i)\)\s*without\s+rowid\s*;?\s*$`)
+
+// loadSQLite reads the tables and the views of schema from the PRAGMA functions, only table when it is not empty.
+func loadSQLite(ctx context.Context, q Queryer, schema string, table string) (*Schema, error) {
+	if schema == "" {
+		schema = "main"
+	}
+	s := &Schema{Name: schema}
+	ddl := map[string]string{}
+	master := `"` + strings.Replace(schema, `"`, `""`, -1) + `".sqlite_master`
+	err := query(ctx, q, func(rows *sql.Rows) error {
+		var name, typ, text string
+		if err := rows.Scan(&name, &typ, &text); err != nil {
+			return err
+		}
+		if typ == "view" {
+			s.Views = append(s.Views, &View{Name: name, Definition: text})
+			return nil
+		}
+		ddl[name] = text
+		s.Tables = append(s.Tables, &Table{Name: name})
+		return nil
+	}, "select name, type, coalesce(sql, '') from "+master+
+		" where type in ('table', 'view') and name not like 'sqlite_%' and (? = '' or name = ?) order by name", table, table)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range s.Tables {
+		if err = loadSQLiteTable(ctx, q, schema, t, ddl[t.Name]); err != nil {
+			return nil, err
+		}
+	}
+	if table != "" {
+		s.Views = nil
+	}
+	return s, nil
+}
+
+func loadSQLiteTable(ctx context.Context, q Queryer, schema string, t *Table, ddl string) error {
+	pk := map[int]string{}
+	err := query(ctx, q, func(rows *sql.Rows) error {
+		c := &Column{}
+		var notNull bool
+		var def sql.NullString
+		var position int
+		if err := rows.Scan(&c.Position, &c.Name, &c.ColumnType, &notNull, &def, &position); err != nil {
+			return err
+		}
+		c.Position++
+		c.DataType = dataType(c.ColumnType)
+		c.Nullable = !notNull
+		if def.Valid {
+			c.Default = &def.String
+		}
+		if position > 0 {
+			c.PrimaryKey = true
+			pk[position] = c.Name
+		}
+		t.Columns = append(t.Columns, c)
+		return nil
+	}, `select cid, name, type, "notnull", dflt_value, pk from pragma_table_info(?, ?) order by cid`, t.Name, schema)
+	if err != nil {
+		return err
+	}
+	for i := 1; i <= len(pk); i++ {
+		t.PrimaryKey = append(t.PrimaryKey, pk[i])
+	}
+	// a single INTEGER PRIMARY KEY is an alias of the rowid, unless the table is WITHOUT ROWID,
+	// which is assigned automatically like an AUTOINCREMENT column.
+	if len(t.PrimaryKey) == 1 && !withoutRowid.MatchString(ddl) {
+		if c := t.Column(t.PrimaryKey[0]); strings.EqualFold(c.ColumnType, "integer") {
+			c.AutoIncrement = true
+		}
+	}
+
+	err = query(ctx, q, func(rows *sql.Rows) error {
+		var origin string
+		idx := &Index{}
+		if err := rows.Scan(&idx.Name, &idx.Unique, &origin); err != nil {
+			return err
+		}
+		if origin != "pk" {
+			t.Indexes = append(t.Indexes, idx)
+		}
+		return nil
+	}, `select name, "unique", origin from pragma_index_list(?, ?)`, t.Name, schema)
+	if err != nil {
+		return err
+	}
+	sort.Slice(t.Indexes, func(i, j int) bool { return t.Indexes[i].Name < t.Indexes[j].Name })
+	for _, idx := range t.Indexes {
+		err = query(ctx, q, func(rows *sql.Rows) error {
+			var column string
+			if err := rows.Scan(&column); err != nil {
+				return err
+			}
+			idx.Columns = append(idx.Columns, column)
+			return nil
+		}, "select coalesce(name, '') from pragma_index_info(?, ?) order by seqno", idx.Name, schema)
+		if err != nil {
+			return err
+		}
+	}
+
+	id := -1
+	return query(ctx, q, func(rows *sql.Rows) error {
+		var n int
+		var refTable, column, refColumn, onUpdate, onDelete string
+		if err := rows.Scan(&n, &refTable, &column, &refColumn, &onUpdate, &onDelete); err != nil {
+			return err
+		}
+		if n != id {
+			id = n
+			t.ForeignKeys = append(t.ForeignKeys, &ForeignKey{RefTable: refTable, OnUpdate: onUpdate, OnDelete: onDelete})
+		}
+		fk := t.ForeignKeys[len(t.ForeignKeys)-1]
+		fk.Columns = append(fk.Columns, column)
+		fk.RefColumns = append(fk.RefColumns, refColumn)
+		return nil
+	}, `select id, "table", "from", coalesce("to", ''), on_update, on_delete from pragma_foreign_key_list(?, ?) order by id, seq`, t.Name, schema)
+}
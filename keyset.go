@@ -0,0 +1,287 @@
+package dbx
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/microbun/dbx/reflectx"
+)
+
+// Keyset describes a keyset (cursor) pagination, it seeks to the rows after or before a cursor
+// instead of skipping them with OFFSET.
+type Keyset struct {
+	// OrderBy are the dbx columns of the struct ordering the rows, a column may be followed by " desc".
+	// The last column must be unique, usually the primary key, to break ties.
+	// The columns must be NOT NULL: a NULL cannot be compared, so Seek returns an error
+	// instead of a cursor when a page starts or ends on a row with a NULL value.
+	OrderBy []string
+	// Size is the maximum number of rows in a page.
+	Size int
+	// Cursor is KeysetPage.Next or KeysetPage.Prev of a previous page, the first page is selected if it is empty.
+	Cursor string
+}
+
+// KeysetPage contains the cursors of the pages around the page returned by Seek.
+type KeysetPage struct {
+	// Next is the cursor of the next page, empty if it is the last page.
+	Next string `json:"next"`
+	// Prev is the cursor of the previous page, empty if it is the first page.
+	Prev string `json:"prev"`
+}
+
+type keysetColumn struct {
+	name string
+	desc bool
+}
+
+// keysetCursor is the content of a cursor token.
+type keysetCursor struct {
+	// Backward is set for the cursor of a previous page.
+	Backward bool              `json:"b,omitempty"`
+	Values   []json.RawMessage `json:"v"`
+}
+
+func parseOrderBy(orderBy []string) ([]keysetColumn, error) {
+	if len(orderBy) == 0 {
+		return nil, errors.New("keyset requires order by columns")
+	}
+	columns := make([]keysetColumn, len(orderBy))
+	for i, s := range orderBy {
+		fields := strings.Fields(s)
+		if len(fields) == 0 || len(fields) > 2 {
+			return nil, fmt.Errorf("invalid order by `%s`", s)
+		}
+		columns[i].name = fields[0]
+		if len(fields) == 2 {
+			switch strings.ToLower(fields[1]) {
+			case "desc":
+				columns[i].desc = true
+			case "asc":
+			default:
+				return nil, fmt.Errorf("invalid order by `%s`", s)
+			}
+		}
+	}
+	return columns, nil
+}
+
+// keysetCondition returns the condition selecting the rows after values in the order of columns.
+// It is the row value comparison (a,b) > (?,?) when all directions are the same and rowValues is set,
+// otherwise the expanded form a > ? or (a = ? and b > ?). The column names are quoted by quote.
+func keysetCondition(columns []keysetColumn, values []interface{}, rowValues bool, quote func(string) string) (string, []interface{}) {
+	op := func(c keysetColumn) string {
+		if c.desc {
+			return "<"
+		}
+		return ">"
+	}
+	sameDirection := true
+	for _, c := range columns {
+		sameDirection = sameDirection && c.desc == columns[0].desc
+	}
+	if rowValues && sameDirection && len(columns) > 1 {
+		names := make([]string, len(columns))
+		for i, c := range columns {
+			names[i] = quote(c.name)
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(columns)), ",")
+		return fmt.Sprintf("(%s) %s (%s)", strings.Join(names, ","), op(columns[0]), placeholders), values
+	}
+	var or []string
+	var args []interface{}
+	for i, c := range columns {
+		var and []string
+		for j := 0; j < i; j++ {
+			and = append(and, quote(columns[j].name)+" = ?")
+			args = append(args, values[j])
+		}
+		and = append(and, quote(c.name)+" "+op(c)+" ?")
+		args = append(args, values[i])
+		or = append(or, strings.Join(and, " and "))
+	}
+	if len(or) == 1 {
+		return or[0], args
+	}
+	return "(" + strings.Join(or, ") or (") + ")", args
+}
+
+// encodeCursor encodes the order by values of the struct v to a cursor token.
+func encodeCursor(v reflect.Value, columns []keysetColumn, backward bool) (string, error) {
+	props := map[string]reflectx.Property{}
+	reflectx.ReflectProperty(v, props)
+	cursor := keysetCursor{Backward: backward}
+	for _, c := range columns {
+		prop, ok := props[c.name]
+		if !ok {
+			return "", fmt.Errorf("missing field `%s` in %s", c.name, v.Type())
+		}
+		if isNullValue(*prop.Value) {
+			return "", fmt.Errorf("order by column `%s` of %s is NULL, keyset columns must be NOT NULL", c.name, v.Type())
+		}
+		b, err := json.Marshal(prop.Value.Interface())
+		if err != nil {
+			return "", err
+		}
+		cursor.Values = append(cursor.Values, b)
+	}
+	b, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// isNullValue reports whether v is stored as NULL, a nil pointer or a driver.Valuer like sql.NullString
+// returning nil.
+func isNullValue(v reflect.Value) bool {
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		return true
+	}
+	valuer, ok := v.Interface().(driver.Valuer)
+	if !ok {
+		return false
+	}
+	value, err := valuer.Value()
+	return err == nil && value == nil
+}
+
+// decodeCursor decodes a cursor token to values typed like the fields of elem.
+func decodeCursor(token string, elem reflect.Type, columns []keysetColumn) (cursor keysetCursor, values []interface{}, err error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cursor, nil, fmt.Errorf("invalid cursor: %v", err)
+	}
+	if err = json.Unmarshal(b, &cursor); err != nil {
+		return cursor, nil, fmt.Errorf("invalid cursor: %v", err)
+	}
+	if len(cursor.Values) != len(columns) {
+		return cursor, nil, errors.New("invalid cursor: order by columns mismatch")
+	}
+	props := map[string]reflectx.Property{}
+	reflectx.ReflectProperty(reflect.New(elem), props)
+	for i, c := range columns {
+		prop, ok := props[c.name]
+		if !ok {
+			return cursor, nil, fmt.Errorf("missing field `%s` in %s", c.name, elem)
+		}
+		pv := reflect.New(prop.Value.Type())
+		if err = json.Unmarshal(cursor.Values[i], pv.Interface()); err != nil {
+			return cursor, nil, fmt.Errorf("invalid cursor: %v", err)
+		}
+		if string(cursor.Values[i]) == "null" || isNullValue(pv.Elem()) {
+			return cursor, nil, fmt.Errorf("invalid cursor: order by column `%s` is NULL", c.name)
+		}
+		values = append(values, fieldValue(pv.Elem()))
+	}
+	return cursor, values, nil
+}
+
+// Seek scans a page of rows to dest, dest must be a pointer to a slice of structs implementing Table.
+// The rows are selected from the table of the struct with the where condition and args, where may be empty.
+// Seek returns the cursors of the next and the previous pages, the cursors encode the order by
+// values of the last and first rows.
+func (e *executor) Seek(ctx context.Context, dest interface{}, where string, ks Keyset, args ...interface{}) (KeysetPage, error) {
+	var page KeysetPage
+	if ks.Size <= 0 {
+		return page, errors.New("page size must be greater than 0")
+	}
+	columns, err := parseOrderBy(ks.OrderBy)
+	if err != nil {
+		return page, err
+	}
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return page, errors.New("dest must be a pointer to slice")
+	}
+	slice := rv.Elem()
+	elem := slice.Type().Elem()
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	if !reflectx.IsStructType(elem) {
+		return page, errors.New("dest must be a slice of struct")
+	}
+	props := map[string]reflectx.Property{}
+	reflectx.ReflectProperty(reflect.New(elem), props)
+	for _, c := range columns {
+		if _, ok := props[c.name]; !ok {
+			return page, fmt.Errorf("order by column `%s` is not a column of %s", c.name, elem)
+		}
+	}
+	table := e.structTable(ctx, reflect.New(elem).Interface())
+	if table == "" {
+		return page, fmt.Errorf("%s not implement Table interface", elem)
+	}
+
+	var cursor keysetCursor
+	var conditions []string
+	if where != "" {
+		conditions = append(conditions, "("+where+")")
+	}
+	order := columns
+	if ks.Cursor != "" {
+		var values []interface{}
+		cursor, values, err = decodeCursor(ks.Cursor, elem, columns)
+		if err != nil {
+			return page, err
+		}
+		if cursor.Backward {
+			order = make([]keysetColumn, len(columns))
+			for i, c := range columns {
+				order[i] = keysetColumn{name: c.name, desc: !c.desc}
+			}
+		}
+		condition, condArgs := keysetCondition(order, values, e.option.Dialect == nil, e.option.quoteIdentifier)
+		conditions = append(conditions, "("+condition+")")
+		args = append(args, condArgs...)
+	}
+	orderBy := make([]string, len(order))
+	for i, c := range order {
+		orderBy[i] = e.option.quoteIdentifier(c.name)
+		if c.desc {
+			orderBy[i] += " desc"
+		}
+	}
+	query := "select * from " + e.option.quoteTable(table)
+	if len(conditions) > 0 {
+		query += " where " + strings.Join(conditions, " and ")
+	}
+	query += " order by " + strings.Join(orderBy, ", ") + e.option.limitOffset(ks.Size+1, 0)
+	if err = e.QueryContext(withTable(ctx, table), dest, query, args...); err != nil {
+		return page, err
+	}
+
+	more := slice.Len() > ks.Size
+	if more {
+		slice.Set(slice.Slice(0, ks.Size))
+	}
+	if cursor.Backward {
+		for i, j := 0, slice.Len()-1; i < j; i, j = i+1, j-1 {
+			vi, vj := slice.Index(i).Interface(), slice.Index(j).Interface()
+			slice.Index(i).Set(reflect.ValueOf(vj))
+			slice.Index(j).Set(reflect.ValueOf(vi))
+		}
+	}
+	if slice.Len() == 0 {
+		return page, nil
+	}
+	first := reflect.Indirect(slice.Index(0))
+	last := reflect.Indirect(slice.Index(slice.Len() - 1))
+	if more || cursor.Backward {
+		if page.Next, err = encodeCursor(last, columns, false); err != nil {
+			return page, err
+		}
+	}
+	if ks.Cursor != "" && (more || !cursor.Backward) {
+		if page.Prev, err = encodeCursor(first, columns, true); err != nil {
+			return page, err
+		}
+	}
+	return page, nil
+}
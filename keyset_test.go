@@ -0,0 +1,98 @@
+package dbx
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"testing"
+)
+
+func seekIDs(t *testing.T, db *DB, ks Keyset) ([]int64, KeysetPage) {
+	var accounts []*accountRecord
+	page, err := db.Seek(context.Background(), &accounts, "status>=?", ks, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]int64, len(accounts))
+	for i, a := range accounts {
+		ids[i] = a.ID
+	}
+	return ids, page
+}
+
+func TestExecutor_Seek(t *testing.T) {
+	db := openSQLite(t)
+	for i := 0; i < 7; i++ {
+		db.MustInsert(&accountRecord{Status: i % 2})
+	}
+	// order: status desc, id => 2,4,6,1,3,5,7
+	ks := Keyset{OrderBy: []string{"status desc", "id"}, Size: 3}
+	ids, page := seekIDs(t, db, ks)
+	if fmt.Sprint(ids) != "[2 4 6]" || page.Prev != "" || page.Next == "" {
+		t.Fatalf("unexpected first page:%v %+v", ids, page)
+	}
+	ks.Cursor = page.Next
+	ids, page = seekIDs(t, db, ks)
+	if fmt.Sprint(ids) != "[1 3 5]" || page.Prev == "" || page.Next == "" {
+		t.Fatalf("unexpected second page:%v %+v", ids, page)
+	}
+	second := page
+	ks.Cursor = page.Next
+	ids, page = seekIDs(t, db, ks)
+	if fmt.Sprint(ids) != "[7]" || page.Prev == "" || page.Next != "" {
+		t.Fatalf("unexpected last page:%v %+v", ids, page)
+	}
+	ks.Cursor = page.Prev
+	ids, page = seekIDs(t, db, ks)
+	if fmt.Sprint(ids) != "[1 3 5]" || page != second {
+		t.Fatalf("unexpected previous page:%v %+v", ids, page)
+	}
+	ks.Cursor = page.Prev
+	ids, page = seekIDs(t, db, ks)
+	if fmt.Sprint(ids) != "[2 4 6]" || page.Prev != "" || page.Next == "" {
+		t.Fatalf("unexpected first page:%v %+v", ids, page)
+	}
+
+	ks.Cursor = "invalid"
+	var accounts []*accountRecord
+	if _, err := db.Seek(context.Background(), &accounts, "", ks); err == nil {
+		t.Fatal("expected invalid cursor error")
+	}
+
+	ks = Keyset{OrderBy: []string{"(select(1))", "id"}, Size: 3}
+	if _, err := db.Seek(context.Background(), &accounts, "", ks); err == nil {
+		t.Fatal("expected an error for an unknown order by column")
+	}
+}
+
+func Test_keysetCondition(t *testing.T) {
+	columns := []keysetColumn{{name: "a"}, {name: "b", desc: true}, {name: "id"}}
+	cond, args := keysetCondition(columns, []interface{}{1, 2, 3}, true, MySQL.Quote)
+	if cond != "(`a` > ?) or (`a` = ? and `b` < ?) or (`a` = ? and `b` = ? and `id` > ?)" || len(args) != 6 {
+		t.Fatalf("unexpected condition:%s %v", cond, args)
+	}
+	columns[1].desc = false
+	cond, args = keysetCondition(columns, []interface{}{1, 2, 3}, true, SQLite.Quote)
+	if cond != `("a","b","id") > (?,?,?)` || len(args) != 3 {
+		t.Fatalf("unexpected condition:%s %v", cond, args)
+	}
+}
+
+func TestExecutor_Seek_Null(t *testing.T) {
+	db := openSQLite(t)
+	db.MustInsert(&accountRecord{Status: 1, NickName: sql.NullString{String: "jack", Valid: true}})
+	db.MustInsert(&accountRecord{Status: 1})
+	db.MustInsert(&accountRecord{Status: 1, NickName: sql.NullString{String: "tom", Valid: true}})
+	// nulls sort first in sqlite, the first page ends on the NULL nickname.
+	ks := Keyset{OrderBy: []string{"nickname", "id"}, Size: 1}
+	var accounts []*accountRecord
+	if _, err := db.Seek(context.Background(), &accounts, "", ks); err == nil {
+		t.Fatal("expected an error for a NULL order by value")
+	}
+
+	ks.Cursor = base64.RawURLEncoding.EncodeToString([]byte(`{"v":[null,1]}`))
+	if _, err := db.Seek(context.Background(), &accounts, "", ks); err == nil {
+		t.Fatal("expected an error for a NULL cursor value")
+	}
+}
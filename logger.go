@@ -1,6 +1,14 @@
 package dbx
 
-import "log"
+import (
+	"context"
+	"log"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
 
 type Logger interface {
 	Printf(sql string)
@@ -14,3 +22,163 @@ func (defaultLogger) Printf(sql string) {
 }
 
 var logger = defaultLogger{}
+
+// DefaultSlowQueryThreshold is used when Options.SlowQueryThreshold is zero.
+const DefaultSlowQueryThreshold = 200 * time.Millisecond
+
+// LogLevel decides which statements are logged.
+type LogLevel int
+
+const (
+	// LogAll logs every statement, it is the default level.
+	LogAll LogLevel = iota
+	// LogSlow logs the statements slower than Options.SlowQueryThreshold and the failed statements.
+	LogSlow
+	// LogError logs the failed statements only.
+	LogError
+	// LogSilent logs nothing.
+	LogSilent
+)
+
+func (o *Options) slowQueryThreshold() time.Duration {
+	if o.SlowQueryThreshold > 0 {
+		return o.SlowQueryThreshold
+	}
+	return DefaultSlowQueryThreshold
+}
+
+// shouldLog reports whether a statement taking d and failing with err is logged.
+func (o *Options) shouldLog(d time.Duration, err error) bool {
+	switch o.LogLevel {
+	case LogAll:
+		return true
+	case LogSlow:
+		return err != nil || d >= o.slowQueryThreshold()
+	case LogError:
+		return err != nil
+	default:
+		return false
+	}
+}
+
+// logQuery sends a statement executed at start to Logger and QueryLogger of option.
+func logQuery(ctx context.Context, option *Options, query string, args []interface{}, start time.Time, rows int64, err error, inTx bool) {
+	if option.Logger == nil && option.QueryLogger == nil {
+		return
+	}
+	d := time.Since(start)
+	if !option.shouldLog(d, err) {
+		return
+	}
+	e := &QueryEvent{
+		Ctx:          ctx,
+		SQL:          query,
+		Args:         args,
+		Start:        start,
+		Duration:     d,
+		RowsAffected: rows,
+		Err:          err,
+		InTx:         inTx,
+		Caller:       caller(),
+	}
+	printSQL(e, option)
+	if option.QueryLogger != nil {
+		option.QueryLogger.LogQuery(e)
+	}
+}
+
+// packagePrefix and subpackagePrefix are the prefixes of the functions of dbx and of its packages like migrate.
+var (
+	packagePrefix    = reflect.TypeOf(Options{}).PkgPath() + "."
+	subpackagePrefix = reflect.TypeOf(Options{}).PkgPath() + "/"
+)
+
+// caller returns file:line of the first frame outside of dbx and its packages,
+// which is the application code issuing the statement.
+func caller() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		inDBX := strings.HasPrefix(frame.Function, packagePrefix) || strings.HasPrefix(frame.Function, subpackagePrefix)
+		if !inDBX || strings.HasSuffix(frame.File, "_test.go") {
+			return frame.File + ":" + strconv.Itoa(frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// QueryEvent describes a statement after it has been executed.
+type QueryEvent struct {
+	// Ctx is the context passed to the statement, it can be used to correlate the event with a request.
+	Ctx context.Context
+	// SQL is the statement with placeholders.
+	SQL string
+	// Args are the arguments bound to the placeholders.
+	Args []interface{}
+	// Start is the time when the statement was sent to the database.
+	Start time.Time
+	// Duration is the time the statement took, including scanning the rows.
+	Duration time.Duration
+	// RowsAffected is the number of rows affected by an exec or the number of rows scanned by a query,
+	// -1 if it is unknown.
+	RowsAffected int64
+	// Err is the error of the statement, if any.
+	Err error
+	// InTx reports whether the statement was executed in a transaction.
+	InTx bool
+	// Caller is the file:line of the application code which issued the statement.
+	Caller string
+}
+
+// QueryLogger receives a QueryEvent after every statement.
+type QueryLogger interface {
+	LogQuery(e *QueryEvent)
+}
+
+// QueryLoggerFunc is an adapter to allow the use of ordinary functions as QueryLogger.
+type QueryLoggerFunc func(e *QueryEvent)
+
+// LogQuery calls f(e).
+func (f QueryLoggerFunc) LogQuery(e *QueryEvent) {
+	f(e)
+}
+
+// KeyValueLogger is a structured logger accepting alternating key/value pairs, *slog.Logger implements it.
+type KeyValueLogger interface {
+	InfoContext(ctx context.Context, msg string, args ...interface{})
+	ErrorContext(ctx context.Context, msg string, args ...interface{})
+}
+
+type keyValueLogger struct {
+	l KeyValueLogger
+}
+
+// NewKeyValueLogger returns a QueryLogger writing every QueryEvent to l,
+// failed statements are written at error level.
+func NewKeyValueLogger(l KeyValueLogger) QueryLogger {
+	return keyValueLogger{l: l}
+}
+
+func (k keyValueLogger) LogQuery(e *QueryEvent) {
+	ctx := e.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	kv := []interface{}{
+		"sql", e.SQL,
+		"args", e.Args,
+		"duration", e.Duration,
+		"rows", e.RowsAffected,
+		"in_tx", e.InTx,
+		"caller", e.Caller,
+	}
+	if e.Err != nil {
+		k.l.ErrorContext(ctx, "query", append(kv, "error", e.Err)...)
+		return
+	}
+	k.l.InfoContext(ctx, "query", kv...)
+}
@@ -0,0 +1,124 @@
+package dbx
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQueryLogger(t *testing.T) {
+	db := openSQLite(t)
+	var events []*QueryEvent
+	db.Options().QueryLogger = QueryLoggerFunc(func(e *QueryEvent) {
+		events = append(events, e)
+	})
+	db.MustInsert(&accountRecord{Status: 1})
+	db.MustInsert(&accountRecord{Status: 1})
+	var accounts []*accountRecord
+	db.MustQuery(&accounts, "select * from accounts")
+	err := db.Transaction(func(tx *Tx) error {
+		_, err := tx.Exec("update accounts set status=?", 2)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec("select * from missing")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	if len(events) != 5 {
+		t.Fatalf("expected 5 events, got %d", len(events))
+	}
+	if events[0].RowsAffected != 1 || events[0].InTx {
+		t.Fatalf("unexpected insert event:%+v", events[0])
+	}
+	if events[2].RowsAffected != 2 {
+		t.Fatalf("unexpected query event:%+v", events[2])
+	}
+	if events[3].RowsAffected != 2 || !events[3].InTx || len(events[3].Args) != 1 {
+		t.Fatalf("unexpected update event:%+v", events[3])
+	}
+	if events[4].Err == nil {
+		t.Fatalf("unexpected failed event:%+v", events[4])
+	}
+}
+
+type testKeyValueLogger struct {
+	info, error int
+	keys        []interface{}
+}
+
+func (l *testKeyValueLogger) InfoContext(ctx context.Context, msg string, args ...interface{}) {
+	l.info++
+	l.keys = args
+}
+
+func (l *testKeyValueLogger) ErrorContext(ctx context.Context, msg string, args ...interface{}) {
+	l.error++
+	l.keys = args
+}
+
+func TestNewKeyValueLogger(t *testing.T) {
+	db := openSQLite(t)
+	kv := &testKeyValueLogger{}
+	db.Options().QueryLogger = NewKeyValueLogger(kv)
+	db.MustExec("update accounts set status=?", 1)
+	_, _ = db.Exec("update missing set status=?", 1)
+	if kv.info != 1 || kv.error != 1 {
+		t.Fatalf("info=%d error=%d", kv.info, kv.error)
+	}
+	if len(kv.keys)%2 != 0 || kv.keys[len(kv.keys)-2] != "error" {
+		t.Fatalf("unexpected key/values:%v", kv.keys)
+	}
+	values := map[interface{}]interface{}{}
+	for i := 0; i < len(kv.keys); i += 2 {
+		values[kv.keys[i]] = kv.keys[i+1]
+	}
+	if caller, _ := values["caller"].(string); !strings.Contains(caller, "logger_test.go:") {
+		t.Fatalf("unexpected caller:%v", values["caller"])
+	}
+}
+
+type testLogger struct {
+	lines []string
+}
+
+func (l *testLogger) Printf(sql string) {
+	l.lines = append(l.lines, sql)
+}
+
+func TestOptions_LogLevel(t *testing.T) {
+	db := openSQLite(t)
+	l := &testLogger{}
+	db.Options().Logger = l
+
+	db.Options().LogLevel = LogError
+	db.MustExec("update accounts set status=?", 1)
+	_, _ = db.Exec("update missing set status=?", 1)
+	// the Logger receives the statement only.
+	if len(l.lines) != 1 || l.lines[0] != "update missing set status=1" {
+		t.Fatalf("unexpected lines:%q", l.lines)
+	}
+
+	l.lines = nil
+	db.Options().LogLevel = LogSlow
+	db.MustExec("update accounts set status=?", 1)
+	if len(l.lines) != 0 {
+		t.Fatalf("unexpected lines:%v", l.lines)
+	}
+	db.Options().SlowQueryThreshold = time.Nanosecond
+	db.MustExec("update accounts set status=?", 1)
+	if len(l.lines) != 1 {
+		t.Fatalf("unexpected lines:%v", l.lines)
+	}
+
+	l.lines = nil
+	db.Options().LogLevel = LogSilent
+	_, _ = db.Exec("update missing set status=?", 1)
+	if len(l.lines) != 0 {
+		t.Fatalf("unexpected lines:%v", l.lines)
+	}
+}
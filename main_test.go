@@ -1,9 +1,11 @@
 package dbx
 
 import (
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -90,6 +92,46 @@ func (r *ExampleRecord) PettyJSON() string {
 //	NetworkID sql.NullInt64  `dbx:"column:network_id"`
 //}
 
+type accountRecord struct {
+	ID        int64          `dbx:"column:id;primary_key;auto_increment"`
+	CreatedAt time.Time      `dbx:"column:created_at;insert:time.Now();update:ignore"`
+	UpdatedAt time.Time      `dbx:"column:updated_at;insert:time.Now();update:time.Now()"`
+	UID       sql.NullInt64  `dbx:"column:uid"`
+	NickName  sql.NullString `dbx:"column:nickname"`
+	Status    int            `dbx:"column:status"`
+	CreatedBy sql.NullString `dbx:"column:created_by"`
+	Avatar    sql.NullString `dbx:"column:avatar"`
+	NetworkID sql.NullInt64  `dbx:"column:network_id"`
+}
+
+func (*accountRecord) TableName() string {
+	return "accounts"
+}
+
+// openSQLite opens a sqlite database in a temporary directory with a accounts table
+func openSQLite(t testing.TB) *DB {
+	db, err := Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "dbx.sqlite"))
+	if err != nil {
+		t.Fatalf("open sqlite:%v", err)
+	}
+	db.Options().Logger = nil
+	db.MustExec(`create table accounts(
+		id         integer primary key autoincrement,
+		created_at datetime,
+		updated_at datetime,
+		uid        integer,
+		nickname   varchar(24),
+		status     integer default 0,
+		created_by varchar(24),
+		avatar     varchar(512),
+		network_id integer
+	)`)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
+
 func TestMain(m *testing.M) {
 	ExampleOpen()
 	code := m.Run()
@@ -16,7 +16,8 @@ const (
 	slice  mode = 2
 )
 
-func mapping(rows *sql.Rows, dest interface{}, m mode) error {
+// mapping scans rows to dest and returns the number of rows scanned.
+func mapping(rows *sql.Rows, dest interface{}, m mode) (int64, error) {
 	defer func() {
 		err := rows.Close()
 		if err != nil {
@@ -26,15 +27,15 @@ func mapping(rows *sql.Rows, dest interface{}, m mode) error {
 	value := reflect.ValueOf(dest)
 	//TODO: add map support
 	if value.Kind() == reflect.Map {
-		return errors.New("dest unsupported map")
+		return 0, errors.New("dest unsupported map")
 	}
 	if value.Kind() != reflect.Ptr {
-		return errors.New("dest must be a ptr")
+		return 0, errors.New("dest must be a ptr")
 	}
 	direct := reflect.Indirect(value)
 	columns, err := rows.Columns()
 	if err != nil {
-		return err
+		return 0, err
 	}
 	switch m {
 	case slice:
@@ -43,11 +44,15 @@ func mapping(rows *sql.Rows, dest interface{}, m mode) error {
 		}
 	case single:
 		{
-			return toSingle(direct, columns, rows)
+			err = toSingle(direct, columns, rows)
+			if err != nil {
+				return 0, err
+			}
+			return 1, nil
 		}
 	default:
 		{
-			return errors.New("unknown scan m")
+			return 0, errors.New("unknown scan m")
 		}
 	}
 }
@@ -79,11 +84,12 @@ func toSingle(dest reflect.Value, columns []string, rows *sql.Rows) error {
 	}
 }
 
-func toSlice(dest reflect.Value, columns []string, rows *sql.Rows) error {
+func toSlice(dest reflect.Value, columns []string, rows *sql.Rows) (int64, error) {
 	kind := dest.Kind()
 	if kind != reflect.Array && kind != reflect.Slice {
-		return fmt.Errorf("argument not a array or slice")
+		return 0, fmt.Errorf("argument not a array or slice")
 	}
+	var n int64
 	valueType := dest.Type().Elem()
 	isPtr := valueType.Kind() == reflect.Ptr
 	if isPtr {
@@ -96,8 +102,9 @@ func toSlice(dest reflect.Value, columns []string, rows *sql.Rows) error {
 				dv := reflect.Indirect(pv)
 				err := rows.Scan(pv.Interface())
 				if err != nil {
-					return err
+					return n, err
 				}
+				n++
 				if isPtr {
 					dest.Set(reflect.Append(dest, pv))
 				} else {
@@ -105,7 +112,7 @@ func toSlice(dest reflect.Value, columns []string, rows *sql.Rows) error {
 				}
 			}
 		} else {
-			return fmt.Errorf("dest slice not a struct or basic")
+			return 0, fmt.Errorf("dest slice not a struct or basic")
 		}
 	} else if reflectx.IsStructType(valueType) {
 
@@ -115,12 +122,13 @@ func toSlice(dest reflect.Value, columns []string, rows *sql.Rows) error {
 			properties := reflectx.NewProperties(len(columns))
 			err := traversal(dv, properties, columns)
 			if err != nil {
-				return err
+				return n, err
 			}
 			err = rows.Scan(properties.Values()...)
 			if err != nil {
-				return err
+				return n, err
 			}
+			n++
 			if isPtr {
 				dest.Set(reflect.Append(dest, pv))
 			} else {
@@ -128,9 +136,9 @@ func toSlice(dest reflect.Value, columns []string, rows *sql.Rows) error {
 			}
 		}
 	} else {
-		return fmt.Errorf("unknown slice type")
+		return 0, fmt.Errorf("unknown slice type")
 	}
-	return nil
+	return n, rows.Err()
 }
 
 func traversal(v reflect.Value, props reflectx.Properties, columns []string) error {
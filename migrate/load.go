@@ -0,0 +1,167 @@
+package migrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Migration is a versioned schema change loaded from NNNN_name.up.sql and NNNN_name.down.sql.
+type Migration struct {
+	Version int64
+	Name    string
+	// Up applies the migration.
+	Up string
+	// Down rolls the migration back, it is empty if there is no down file.
+	Down string
+}
+
+// Checksum returns the hex encoded SHA-256 of the up statements, it detects an applied migration which has been edited.
+func (m *Migration) Checksum() string {
+	sum := sha256.Sum256([]byte(m.Up))
+	return hex.EncodeToString(sum[:])
+}
+
+var fileRegexp = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Load reads the migrations in the directory dir of fsys, like an embed.FS, sorted by version.
+// The files of other names are ignored.
+func Load(fsys fs.FS, dir string) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := fileRegexp.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %v", entry.Name(), err)
+		}
+		b, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(b)
+		} else {
+			m.Down = string(b)
+		}
+	}
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if strings.TrimSpace(m.Up) == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up statements", m.Version, m.Name)
+		}
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// LoadDir reads the migrations in the directory dir of the file system.
+func LoadDir(dir string) ([]*Migration, error) {
+	return Load(os.DirFS(dir), ".")
+}
+
+// splitStatements splits a script on the semicolons outside of quotes, comments and BEGIN ... END blocks
+// of the CREATE statements like a trigger, the statements made of comments only are dropped.
+// The # comments and the backslash escapes in quotes are MySQL only.
+func splitStatements(script string, mysql bool) []string {
+	var statements []string
+	start, code := 0, false
+	// first is the first word of the statement, depth the number of open BEGIN and CASE blocks.
+	first, depth := "", 0
+	add := func(end int) {
+		if code {
+			statements = append(statements, strings.TrimSpace(script[start:end]))
+		}
+	}
+	for i := 0; i < len(script); i++ {
+		switch c := script[i]; {
+		case c == '\'' || c == '"' || c == '`':
+			code = true
+			for i++; i < len(script) && script[i] != c; i++ {
+				if script[i] == '\\' && mysql {
+					i++
+				}
+			}
+		case c == '-' && strings.HasPrefix(script[i:], "--"), c == '#' && mysql:
+			for i < len(script) && script[i] != '\n' {
+				i++
+			}
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				i = len(script)
+			} else {
+				i += end + 3
+			}
+		case c == ';':
+			if depth > 0 {
+				continue
+			}
+			add(i)
+			start, code, first = i+1, false, ""
+		case isWordByte(c):
+			j := i
+			for j < len(script) && isWordByte(script[j]) {
+				j++
+			}
+			word := strings.ToUpper(script[i:j])
+			if first == "" {
+				first = word
+			}
+			switch {
+			case word == "BEGIN" && first == "CREATE", word == "CASE":
+				depth++
+			case word == "END" && depth > 0 && !endsControlFlow(script[j:]):
+				depth--
+			}
+			code = true
+			i = j - 1
+		case c != ' ' && c != '\t' && c != '\n' && c != '\r':
+			code = true
+		}
+	}
+	add(len(script))
+	return statements
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// endsControlFlow reports whether the END before rest closes a IF, LOOP, WHILE or REPEAT of MySQL,
+// whose opening words are not counted.
+func endsControlFlow(rest string) bool {
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return false
+	}
+	word := strings.ToUpper(strings.TrimRight(fields[0], ";"))
+	return word == "IF" || word == "LOOP" || word == "WHILE" || word == "REPEAT"
+}
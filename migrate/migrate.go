@@ -0,0 +1,331 @@
+// Package migrate applies versioned SQL migrations to a dbx.DB.
+//
+// A migration is a pair of files NNNN_name.up.sql and NNNN_name.down.sql, the statements of a file are
+// separated by the semicolons out of quotes, comments and the BEGIN ... END body of a trigger.
+// The applied versions are recorded with the checksum of their up file in the dbx_migrations table.
+// The migrations are applied in a transaction when the database supports transactional DDL, like SQLite,
+// and a lock prevents concurrent runners: GET_LOCK for MySQL and a lock table for the other databases.
+// The lock left in the lock table by a crashed runner is taken over once it is older than Migrator.StaleLock,
+// or removed by Migrator.Unlock.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/microbun/dbx"
+)
+
+// DefaultTable is the table recording the applied migrations.
+const DefaultTable = "dbx_migrations"
+
+// ErrLocked is returned when another runner holds the migration lock.
+var ErrLocked = errors.New("migrate: migrations are locked by another runner")
+
+// ChecksumError is returned when a migration has been edited after it has been applied.
+type ChecksumError struct {
+	Version int64
+	Name    string
+	// Applied is the checksum recorded when the migration was applied.
+	Applied string
+	// Current is the checksum of the up file.
+	Current string
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("migrate: migration %d_%s has been edited after it was applied (checksum %s, applied %s)",
+		e.Version, e.Name, e.Current, e.Applied)
+}
+
+// Status is a migration and whether it has been applied.
+type Status struct {
+	*Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// applied is a row of the migrations table.
+type applied struct {
+	Version   int64     `dbx:"column:version"`
+	Name      string    `dbx:"column:name"`
+	Checksum  string    `dbx:"column:checksum"`
+	AppliedAt time.Time `dbx:"column:applied_at"`
+}
+
+// Migrator applies migrations to a database.
+type Migrator struct {
+	db         *dbx.DB
+	migrations []*Migration
+	// Table records the applied migrations, DefaultTable if it is empty.
+	Table string
+	// LockTimeout is the time waited for the lock of another runner before ErrLocked is returned.
+	LockTimeout time.Duration
+	// StaleLock is the age of a lock of the lock table after which it is considered left by a crashed runner
+	// and taken over, it must be longer than the longest run. A stale lock is never taken over if it is 0.
+	StaleLock time.Duration
+}
+
+// lockPollInterval is the interval between two attempts to take the lock of the lock table.
+var lockPollInterval = 100 * time.Millisecond
+
+// New returns a Migrator applying migrations to db, the migrations are usually loaded by Load.
+func New(db *dbx.DB, migrations []*Migration) *Migrator {
+	return &Migrator{db: db, migrations: migrations, Table: DefaultTable, LockTimeout: 10 * time.Second, StaleLock: time.Hour}
+}
+
+func (m *Migrator) table() string {
+	if m.Table == "" {
+		return DefaultTable
+	}
+	return m.Table
+}
+
+func (m *Migrator) dialect() dbx.Dialect {
+	return m.db.Options().Dialect
+}
+
+// transactional reports whether the DDL statements are run in a transaction,
+// MySQL commits a transaction implicitly before any DDL statement.
+func (m *Migrator) transactional() bool {
+	return m.dialect() != dbx.MySQL
+}
+
+func (m *Migrator) createTable(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx, fmt.Sprintf(`create table if not exists %s (
+		version    bigint not null primary key,
+		name       varchar(255) not null,
+		checksum   varchar(64) not null,
+		applied_at datetime not null
+	)`, m.table()))
+	return err
+}
+
+// lock takes the migration lock, the returned function releases it.
+func (m *Migrator) lock(ctx context.Context) (func() error, error) {
+	if m.dialect() == dbx.MySQL {
+		// GET_LOCK belongs to the session, so the lock is taken and released on the same connection.
+		conn, err := m.db.RawDB().Conn(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var got sql.NullInt64
+		err = conn.QueryRowContext(ctx, "select get_lock(?, ?)", m.table(), int(m.LockTimeout/time.Second)).Scan(&got)
+		if err != nil || got.Int64 != 1 {
+			_ = conn.Close()
+			if err == nil {
+				err = ErrLocked
+			}
+			return nil, err
+		}
+		return func() error {
+			defer conn.Close()
+			_, err := conn.ExecContext(context.Background(), "do release_lock(?)", m.table())
+			return err
+		}, nil
+	}
+	lockTable, err := m.lockTable(ctx)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(m.LockTimeout)
+	for {
+		// the times are in UTC, so that they compare as the strings of SQLite.
+		_, err = m.db.ExecContext(ctx, fmt.Sprintf("insert into %s(id, locked_at) values(1, ?)", lockTable), time.Now().UTC())
+		if err == nil {
+			break
+		}
+		if !dbx.IsUniqueViolation(err) {
+			return nil, err
+		}
+		if m.StaleLock > 0 {
+			rs, err := m.db.ExecContext(ctx, fmt.Sprintf("delete from %s where id = 1 and locked_at < ?", lockTable),
+				time.Now().Add(-m.StaleLock).UTC())
+			if err != nil {
+				return nil, err
+			}
+			if n, _ := rs.RowsAffected(); n > 0 {
+				continue
+			}
+		}
+		if !time.Now().Before(deadline) {
+			return nil, ErrLocked
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+	return func() error {
+		_, err := m.db.ExecContext(context.Background(), fmt.Sprintf("delete from %s where id = 1", lockTable))
+		return err
+	}, nil
+}
+
+// lockTable creates the lock table if it doesn't exist and returns its name.
+func (m *Migrator) lockTable(ctx context.Context) (string, error) {
+	lockTable := m.table() + "_lock"
+	_, err := m.db.ExecContext(ctx, fmt.Sprintf("create table if not exists %s (id integer not null primary key, locked_at datetime not null)", lockTable))
+	return lockTable, err
+}
+
+// Unlock removes the lock of the lock table left by a crashed runner, it must not be called while a runner is running.
+// The lock of MySQL is released by the database when the session of the runner ends.
+func (m *Migrator) Unlock(ctx context.Context) error {
+	if m.dialect() == dbx.MySQL {
+		return nil
+	}
+	lockTable, err := m.lockTable(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = m.db.ExecContext(ctx, fmt.Sprintf("delete from %s where id = 1", lockTable))
+	return err
+}
+
+// locked runs fn holding the lock after the migrations table has been created.
+func (m *Migrator) locked(ctx context.Context, fn func() error) (err error) {
+	if err = m.createTable(ctx); err != nil {
+		return err
+	}
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if e := unlock(); e != nil && err == nil {
+			err = e
+		}
+	}()
+	return fn()
+}
+
+// applied returns the applied migrations by version.
+func (m *Migrator) applied(ctx context.Context) (map[int64]applied, error) {
+	var rows []applied
+	err := m.db.QueryContext(dbx.ForcePrimary(ctx), &rows, fmt.Sprintf("select version, name, checksum, applied_at from %s", m.table()))
+	if err != nil {
+		return nil, err
+	}
+	versions := make(map[int64]applied, len(rows))
+	for _, row := range rows {
+		versions[row.Version] = row
+	}
+	return versions, nil
+}
+
+// verify returns a *ChecksumError if an applied migration has been edited.
+func (m *Migrator) verify(versions map[int64]applied) error {
+	for _, migration := range m.migrations {
+		row, ok := versions[migration.Version]
+		if ok && row.Checksum != migration.Checksum() {
+			return &ChecksumError{Version: migration.Version, Name: migration.Name, Applied: row.Checksum, Current: migration.Checksum()}
+		}
+	}
+	return nil
+}
+
+// run executes the statements of a migration and records it with record, in a transaction if the dialect allows it.
+func (m *Migrator) run(ctx context.Context, migration *Migration, script string, record string, args ...interface{}) error {
+	statements := splitStatements(script, m.dialect() == dbx.MySQL)
+	exec := func(e dbx.Executor) error {
+		for _, s := range statements {
+			if _, err := e.ExecContext(ctx, s); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+		}
+		_, err := e.ExecContext(ctx, record, args...)
+		return err
+	}
+	if !m.transactional() {
+		return exec(m.db)
+	}
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err = exec(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Up applies the pending migrations in the order of their versions and returns them.
+// Nothing is applied if an applied migration has been edited, a *ChecksumError is returned instead.
+func (m *Migrator) Up(ctx context.Context) (done []*Migration, err error) {
+	err = m.locked(ctx, func() error {
+		versions, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+		if err = m.verify(versions); err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if _, ok := versions[migration.Version]; ok {
+				continue
+			}
+			record := fmt.Sprintf("insert into %s(version, name, checksum, applied_at) values(?, ?, ?, ?)", m.table())
+			err = m.run(ctx, migration, migration.Up, record, migration.Version, migration.Name, migration.Checksum(), time.Now())
+			if err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down rolls back the last steps applied migrations, newest first, and returns them.
+func (m *Migrator) Down(ctx context.Context, steps int) (done []*Migration, err error) {
+	err = m.locked(ctx, func() error {
+		versions, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+		if err = m.verify(versions); err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := versions[migration.Version]; !ok {
+				continue
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("migration %d_%s has no down statements", migration.Version, migration.Name)
+			}
+			record := fmt.Sprintf("delete from %s where version = ?", m.table())
+			if err = m.run(ctx, migration, migration.Down, record, migration.Version); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status returns the status of every migration, or a *ChecksumError if an applied migration has been edited.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	if err := m.createTable(ctx); err != nil {
+		return nil, err
+	}
+	versions, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = m.verify(versions); err != nil {
+		return nil, err
+	}
+	status := make([]Status, len(m.migrations))
+	for i, migration := range m.migrations {
+		row, ok := versions[migration.Version]
+		status[i] = Status{Migration: migration, Applied: ok, AppliedAt: row.AppliedAt}
+	}
+	return status, nil
+}
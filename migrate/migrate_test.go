@@ -0,0 +1,215 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/microbun/dbx"
+)
+
+func openSQLite(t *testing.T) *dbx.DB {
+	db, err := dbx.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "migrate.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Options().Logger = nil
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
+
+var files = fstest.MapFS{
+	"migrations/0001_users.up.sql":      {Data: []byte("create table users(id integer primary key, name varchar(24)); -- users\ncreate index users_name on users(name);")},
+	"migrations/0001_users.down.sql":    {Data: []byte("drop table users;")},
+	"migrations/0002_orders.up.sql":     {Data: []byte("create table orders(id integer primary key, note varchar(24) default 'a;b');")},
+	"migrations/0002_orders.down.sql":   {Data: []byte("drop table orders;")},
+	"migrations/0003_broken.up.sql":     {Data: []byte("create table accounts(id integer primary key);\ninsert into missing values(1);")},
+	"migrations/README.md":              {Data: []byte("not a migration")},
+	"migrations/0010_nodown.up.sql.bak": {Data: []byte("ignored")},
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load(files, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 3 {
+		t.Fatalf("migrations=%d", len(migrations))
+	}
+	m := migrations[1]
+	if m.Version != 2 || m.Name != "orders" || m.Down != "drop table orders;" {
+		t.Errorf("migration=%+v", m)
+	}
+	_, err = Load(fstest.MapFS{"0001_a.down.sql": {Data: []byte("drop table a")}}, ".")
+	if err == nil {
+		t.Error("load migration without up file")
+	}
+}
+
+func Test_splitStatements(t *testing.T) {
+	tests := []struct {
+		script string
+		mysql  bool
+		want   []string
+	}{
+		{
+			script: "create table a(b varchar(3) default ';');\n-- c;\n/* d; */ insert into a values(\"e;\");\n-- end",
+			want:   []string{"create table a(b varchar(3) default ';')", "-- c;\n/* d; */ insert into a values(\"e;\")"},
+		},
+		{
+			script: "create table a(b text default 'C:\\');\ninsert into a values('x;y');",
+			want:   []string{"create table a(b text default 'C:\\')", "insert into a values('x;y')"},
+		},
+		{
+			script: "insert into a values('it\\'s;');\n# comment;\ninsert into a values(1);",
+			mysql:  true,
+			want:   []string{"insert into a values('it\\'s;')", "# comment;\ninsert into a values(1)"},
+		},
+		{
+			script: "begin;\ncreate trigger t after insert on a begin\n  update b set n = case when n > 0 then n + 1 else 1 end;\n  delete from c;\nend;\ncommit;",
+			want: []string{"begin", "create trigger t after insert on a begin\n  update b set n = case when n > 0 then n + 1 else 1 end;\n  delete from c;\nend",
+				"commit"},
+		},
+		{
+			script: "create procedure p() begin\n  if 1 then select 1; end if;\nend;\nselect 2;",
+			mysql:  true,
+			want:   []string{"create procedure p() begin\n  if 1 then select 1; end if;\nend", "select 2"},
+		},
+	}
+	for _, tt := range tests {
+		if got := splitStatements(tt.script, tt.mysql); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitStatements(%q)=%q, want %q", tt.script, got, tt.want)
+		}
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	migrations, err := Load(files, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := New(db, migrations)
+
+	done, err := m.Up(ctx)
+	if err == nil || len(done) != 2 {
+		t.Fatalf("up done=%d err=%v", len(done), err)
+	}
+	// the failed migration has been rolled back with its first statement
+	var tables []string
+	db.MustQuery(&tables, "select name from sqlite_master where type = 'table' and name in ('users', 'orders', 'accounts') order by name")
+	if !reflect.DeepEqual(tables, []string{"orders", "users"}) {
+		t.Errorf("tables=%v", tables)
+	}
+
+	m = New(db, migrations[:2])
+	if done, err = m.Up(ctx); err != nil || len(done) != 0 {
+		t.Fatalf("up again done=%d err=%v", len(done), err)
+	}
+	status, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status[0].Applied || !status[1].Applied || status[1].AppliedAt.IsZero() {
+		t.Errorf("status=%+v", status)
+	}
+
+	if done, err = m.Down(ctx, 1); err != nil || len(done) != 1 || done[0].Version != 2 {
+		t.Fatalf("down done=%v err=%v", done, err)
+	}
+	if status, _ = m.Status(ctx); status[1].Applied {
+		t.Error("migration 2 applied after down")
+	}
+
+	edited := *migrations[0]
+	edited.Up += "\ncreate index users_id on users(id);"
+	_, err = New(db, []*Migration{&edited, migrations[1]}).Up(ctx)
+	var checksum *ChecksumError
+	if !errors.As(err, &checksum) || checksum.Version != 1 {
+		t.Fatalf("edited migration err=%v", err)
+	}
+	if status, _ = m.Status(ctx); status[1].Applied {
+		t.Error("migration applied after a checksum error")
+	}
+}
+
+func TestMigrator_Lock(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	migrations, err := Load(files, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lockPollInterval = 10 * time.Millisecond
+	defer func() {
+		lockPollInterval = 100 * time.Millisecond
+	}()
+	m := New(db, migrations[:1])
+	err = m.locked(ctx, func() error {
+		other := New(db, migrations[:1])
+		other.LockTimeout = 50 * time.Millisecond
+		start := time.Now()
+		_, err := other.Up(ctx)
+		if time.Since(start) < other.LockTimeout {
+			t.Errorf("the lock was not waited for")
+		}
+		return err
+	})
+	if !errors.Is(err, ErrLocked) {
+		t.Fatalf("concurrent up err=%v", err)
+	}
+	if _, err = m.Up(ctx); err != nil {
+		t.Fatalf("up after unlock err=%v", err)
+	}
+
+	// a crashed runner leaves its lock.
+	if _, err = m.lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	m.LockTimeout = 0
+	if _, err = m.Up(ctx); !errors.Is(err, ErrLocked) {
+		t.Fatalf("up with a recent lock err=%v", err)
+	}
+	db.MustExec("update dbx_migrations_lock set locked_at = ?", time.Now().Add(-2*time.Hour).UTC())
+	if _, err = m.Up(ctx); err != nil {
+		t.Fatalf("up with a stale lock err=%v", err)
+	}
+	if _, err = m.lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err = m.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = m.Up(ctx); err != nil {
+		t.Fatalf("up after Unlock err=%v", err)
+	}
+}
+
+func TestMigrator_Caller(t *testing.T) {
+	db := openSQLite(t)
+	var callers []string
+	db.Options().QueryLogger = dbx.QueryLoggerFunc(func(e *dbx.QueryEvent) {
+		callers = append(callers, e.Caller)
+	})
+	migrations, err := Load(files, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = New(db, migrations[:1]).Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for _, caller := range callers {
+		if !strings.Contains(caller, "migrate_test.go:") {
+			t.Fatalf("the caller of a statement of the migrator is %s", caller)
+		}
+	}
+}
@@ -1,71 +1,399 @@
 package dbx
 
 import (
+	"database/sql/driver"
+	"errors"
 	"fmt"
 	"reflect"
-	"regexp"
-	"sort"
 	"strings"
+	"sync"
 
 	"github.com/microbun/dbx/reflectx"
 )
 
-var reg = regexp.MustCompile(":\\w+")
+// NamedStyle is a set of prefixes recognised as named parameters.
+type NamedStyle int
 
-func namedCompile(query string, p map[string]interface{}) (string, []interface{}, error) {
-	args := make([]interface{}, 0)
-	matched := reg.FindAllString(query, -1)
-	placeholders := map[string]string{}
-	for _, parameter := range matched {
-		value, ok := p[parameter[1:]]
-		if !ok {
-			return "", nil, fmt.Errorf("`%s` not found ", parameter)
-		}
-		rt := reflect.TypeOf(value)
-		rv := reflect.ValueOf(value)
-		if reflectx.IsBasicType(rt) {
-			args = append(args, value)
-			placeholders[parameter] = "?"
-		} else if reflectx.IsSliceType(rt) {
-			if rt.Elem().String() == "uint8" {
-				args = append(args, value)
-				placeholders[parameter] = "?"
-				continue
+const (
+	// NamedColon recognises :name, it is the default style.
+	NamedColon NamedStyle = 1 << iota
+	// NamedAt recognises @name, @@name is left untouched for MySQL system variables.
+	NamedAt
+	// NamedDollar recognises $name, positional parameters like $1 are left untouched.
+	NamedDollar
+)
+
+// namedSyntax decides how a named query is tokenized.
+type namedSyntax struct {
+	style NamedStyle
+	// mysql enables backslash escapes in quoted strings and # comments,
+	// the other dialects have dollar-quoted strings like $$text$$ instead.
+	mysql bool
+}
+
+var defaultNamedSyntax = namedSyntax{style: NamedColon, mysql: true}
+
+func (o *Options) namedSyntax() namedSyntax {
+	syntax := namedSyntax{style: o.NamedStyle, mysql: o.Dialect == MySQL}
+	if syntax.style == 0 {
+		syntax.style = NamedColon
+	}
+	return syntax
+}
+
+var valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
+// namedTemplate is a tokenized named query, the parameter names[i] is between parts[i] and parts[i+1].
+type namedTemplate struct {
+	parts []string
+	names []string
+	// valuesOpen and valuesClose are the offsets of the parentheses of the first VALUES list, -1 if there is none.
+	valuesOpen  int
+	valuesClose int
+}
+
+const namedCacheSize = 1024
+
+// namedCache keeps the compiled templates per query string.
+var namedCache = struct {
+	sync.Mutex
+	templates map[namedCacheKey]*namedTemplate
+}{templates: map[namedCacheKey]*namedTemplate{}}
+
+type namedCacheKey struct {
+	syntax namedSyntax
+	query  string
+}
+
+// compileNamed returns the template of query, the templates are cached.
+func compileNamed(query string, syntax namedSyntax) *namedTemplate {
+	key := namedCacheKey{syntax: syntax, query: query}
+	namedCache.Lock()
+	t, ok := namedCache.templates[key]
+	namedCache.Unlock()
+	if ok {
+		return t
+	}
+	t = lexNamed(query, syntax)
+	namedCache.Lock()
+	if len(namedCache.templates) >= namedCacheSize {
+		namedCache.templates = map[namedCacheKey]*namedTemplate{}
+	}
+	namedCache.templates[key] = t
+	namedCache.Unlock()
+	return t
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || c >= '0' && c <= '9'
+}
+
+// lexNamed splits query into literal parts and parameter names.
+// Quoted strings, quoted identifiers and comments are copied as they are, :: casts are not parameters,
+// and a prefix escaped by a backslash like \: is a literal.
+func lexNamed(query string, syntax namedSyntax) *namedTemplate {
+	t := &namedTemplate{valuesOpen: -1, valuesClose: -1}
+	var part strings.Builder
+	depth, valuesDepth := 0, -1
+	afterValues := false
+	n := len(query)
+	for i := 0; i < n; {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := skipQuoted(query, i, syntax.mysql && c != '`')
+			part.WriteString(query[i:end])
+			i = end
+			afterValues = false
+			continue
+		case c == '$' && !syntax.mysql && dollarQuoted(query, i) > i:
+			end := dollarQuoted(query, i)
+			part.WriteString(query[i:end])
+			i = end
+			afterValues = false
+			continue
+		case c == '-' && i+1 < n && query[i+1] == '-', c == '#' && syntax.mysql:
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = n
+			} else {
+				end += i
 			}
-			if rt.Elem().String() != "[]uint8" && rt.Elem().String() != "[]int8" && !reflectx.IsBasicType(rt.Elem()) {
-				return "", nil, fmt.Errorf("unsupport args type at %s", parameter)
+			part.WriteString(query[i:end])
+			i = end
+			continue
+		case c == '/' && i+1 < n && query[i+1] == '*':
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				end = n
+			} else {
+				end += i + 4
 			}
-			repeat := make([]string, 0)
-			if rv.Len() == 0 {
-				return "", nil, fmt.Errorf("`%s` len must be greater than 0", parameter)
+			part.WriteString(query[i:end])
+			i = end
+			continue
+		case c == '\\' && i+1 < n && syntax.prefix(query[i+1]):
+			part.WriteByte(query[i+1])
+			i += 2
+			continue
+		case c == ':' && i+1 < n && query[i+1] == ':':
+			part.WriteString("::")
+			i += 2
+			continue
+		case c == '@' && i+1 < n && query[i+1] == '@':
+			end := i + 2
+			for end < n && (isNameChar(query[end]) || query[end] == '.') {
+				end++
 			}
-			for j := 0; j < rv.Len(); j++ {
-				args = append(args, rv.Index(j).Interface())
-				repeat = append(repeat, "?")
+			part.WriteString(query[i:end])
+			i = end
+			continue
+		case syntax.prefix(c) && i+1 < n && (isNameStart(query[i+1]) || c == ':' && isNameChar(query[i+1])):
+			end := i + 1
+			for end < n && isNameChar(query[end]) {
+				end++
 			}
-			placeholders[parameter] = strings.Join(repeat, ",")
-		} else if reflectx.IsStructType(rt) {
-			if len(p) > 1 {
-				return "", nil, fmt.Errorf("unsupport args type at %s", parameter)
-			} else {
-				//解析结构体的参数
-				return "", nil, fmt.Errorf("unsupport key type %s", parameter)
+			t.parts = append(t.parts, part.String())
+			t.names = append(t.names, query[i+1:end])
+			part.Reset()
+			i = end
+			afterValues = false
+			continue
+		case isNameStart(c):
+			end := i + 1
+			for end < n && isNameChar(query[end]) {
+				end++
+			}
+			afterValues = t.valuesOpen < 0 && strings.EqualFold(query[i:end], "values")
+			part.WriteString(query[i:end])
+			i = end
+			continue
+		case c == '(':
+			if afterValues {
+				t.valuesOpen, valuesDepth = i, depth
+			}
+			depth++
+		case c == ')':
+			depth--
+			if depth == valuesDepth && t.valuesClose < 0 {
+				t.valuesClose = i
+			}
+		}
+		if c != ' ' && c != '\t' && c != '\n' && c != '\r' {
+			afterValues = false
+		}
+		part.WriteByte(c)
+		i++
+	}
+	t.parts = append(t.parts, part.String())
+	return t
+}
+
+// prefix reports whether c starts a named parameter in the syntax.
+func (s namedSyntax) prefix(c byte) bool {
+	switch c {
+	case ':':
+		return s.style&NamedColon != 0
+	case '@':
+		return s.style&NamedAt != 0
+	case '$':
+		return s.style&NamedDollar != 0
+	}
+	return false
+}
+
+// dollarQuoted returns the offset after the dollar-quoted string starting at query[start] like $tag$text$tag$,
+// or start if there is none.
+func dollarQuoted(query string, start int) int {
+	i := start + 1
+	if i < len(query) && isNameStart(query[i]) {
+		for i < len(query) && isNameChar(query[i]) {
+			i++
+		}
+	}
+	if i >= len(query) || query[i] != '$' {
+		return start
+	}
+	tag := query[start : i+1]
+	end := strings.Index(query[i+1:], tag)
+	if end < 0 {
+		return start
+	}
+	return i + 1 + end + len(tag)
+}
+
+// skipQuoted returns the offset after the quoted text starting at query[start],
+// a doubled quote is an escaped quote, and so is a quote after a backslash if backslash is set.
+func skipQuoted(query string, start int, backslash bool) int {
+	quote := query[start]
+	for i := start + 1; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			if backslash {
+				i++
+			}
+		case quote:
+			if i+1 < len(query) && query[i+1] == quote {
+				i++
+				continue
 			}
-		} else {
-			return "", nil, fmt.Errorf("unsupport args type at %s", parameter)
+			return i + 1
+		}
+	}
+	return len(query)
+}
+
+// bind replaces the parameters with placeholders, a slice parameter is expanded to one placeholder per element.
+func (t *namedTemplate) bind(p map[string]interface{}) (string, []interface{}, error) {
+	var query strings.Builder
+	args := make([]interface{}, 0, len(t.names))
+	query.WriteString(t.parts[0])
+	for i, name := range t.names {
+		value, ok := p[name]
+		if !ok {
+			return "", nil, fmt.Errorf("`:%s` not found ", name)
 		}
+		placeholder, values, err := bindValue(name, value)
+		if err != nil {
+			return "", nil, err
+		}
+		query.WriteString(placeholder)
+		query.WriteString(t.parts[i+1])
+		args = append(args, values...)
+	}
+	return query.String(), args, nil
+}
+
+// bindValue returns the placeholder and the arguments of a named parameter.
+func bindValue(name string, value interface{}) (string, []interface{}, error) {
+	if value == nil {
+		return "?", []interface{}{nil}, nil
+	}
+	rt := reflect.TypeOf(value)
+	rv := reflect.ValueOf(value)
+	if reflectx.IsBasicType(rt) || rt.Implements(valuerType) {
+		return "?", []interface{}{value}, nil
+	}
+	if !reflectx.IsSliceType(rt) {
+		return "", nil, fmt.Errorf("unsupport args type at :%s", name)
+	}
+	if rt.Elem().Kind() == reflect.Uint8 {
+		return "?", []interface{}{value}, nil
+	}
+	if rt.Elem().String() != "[]uint8" && rt.Elem().String() != "[]int8" && !reflectx.IsBasicType(rt.Elem()) {
+		return "", nil, fmt.Errorf("unsupport args type at :%s", name)
+	}
+	if rv.Len() == 0 {
+		return "", nil, fmt.Errorf("`:%s` len must be greater than 0", name)
+	}
+	args := make([]interface{}, rv.Len())
+	for j := range args {
+		args[j] = rv.Index(j).Interface()
 	}
+	return strings.TrimSuffix(strings.Repeat("?,", rv.Len()), ","), args, nil
+}
+
+// namedCompile replaces the :name parameters of query with placeholders and returns the arguments in order.
+func namedCompile(query string, arg interface{}) (string, []interface{}, error) {
+	return namedCompileSyntax(query, arg, defaultNamedSyntax)
+}
 
-	params := make([]string, 0)
-	for p := range placeholders {
-		params = append(params, p)
+// namedCompileSyntax replaces the named parameters of query with placeholders and returns the arguments in order.
+// arg is a map with string keys, a struct or a pointer to a struct,
+// or a slice of them whose elements are bound to copies of the VALUES list of query.
+func namedCompileSyntax(query string, arg interface{}, syntax namedSyntax) (string, []interface{}, error) {
+	t := compileNamed(query, syntax)
+	rv := reflect.Indirect(reflect.ValueOf(arg))
+	if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		return namedCompileBatch(query, t, rv, syntax)
 	}
-	sort.Sort(sort.Reverse(sort.StringSlice(params)))
-	for _, key := range params {
-		placeholder := placeholders[key]
-		query = strings.ReplaceAll(query, key, placeholder)
+	p, err := namedArgs(arg)
+	if err != nil {
+		return "", nil, err
 	}
+	return t.bind(p)
+}
 
-	return query, args, nil
+// namedArgs returns the named values of arg,
+// the names of a struct are the dbx columns of its fields, including nested and embedded structs.
+func namedArgs(arg interface{}) (map[string]interface{}, error) {
+	if p, ok := arg.(map[string]interface{}); ok {
+		return p, nil
+	}
+	rv := reflect.Indirect(reflect.ValueOf(arg))
+	switch {
+	case rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String:
+		p := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			p[iter.Key().String()] = iter.Value().Interface()
+		}
+		return p, nil
+	case rv.Kind() == reflect.Struct && reflectx.IsStructType(rv.Type()):
+		if !rv.CanAddr() {
+			copied := reflect.New(rv.Type()).Elem()
+			copied.Set(rv)
+			rv = copied
+		}
+		props := map[string]reflectx.Property{}
+		reflectx.ReflectProperty(rv, props)
+		p := make(map[string]interface{}, len(props))
+		for name, prop := range props {
+			p[name] = fieldValue(*prop.Value)
+		}
+		return p, nil
+	case !rv.IsValid():
+		return nil, errors.New("named args is nil")
+	}
+	return nil, fmt.Errorf("unsupport named args type %s", rv.Type())
+}
 
+// fieldValue returns the value of a struct field, a nil pointer is nil and other pointers are dereferenced.
+func fieldValue(v reflect.Value) interface{} {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	return v.Interface()
+}
+
+// namedCompileBatch binds every element of rv to a copy of the VALUES list of query,
+// for example "insert into t(a,b) values(:a,:b)" becomes "insert into t(a,b) values(?,?),(?,?)".
+func namedCompileBatch(query string, t *namedTemplate, rv reflect.Value, syntax namedSyntax) (string, []interface{}, error) {
+	if rv.Len() == 0 {
+		return "", nil, errors.New("named args is a empty slice")
+	}
+	if t.valuesOpen < 0 {
+		return "", nil, errors.New("batch named args require a VALUES list")
+	}
+	if t.valuesClose < 0 {
+		return "", nil, errors.New("unclosed VALUES list")
+	}
+	prefix := compileNamed(query[:t.valuesOpen], syntax)
+	suffix := compileNamed(query[t.valuesClose+1:], syntax)
+	if len(prefix.names) > 0 || len(suffix.names) > 0 {
+		return "", nil, errors.New("batch named parameters must be in the VALUES list")
+	}
+	group := compileNamed(query[t.valuesOpen:t.valuesClose+1], syntax)
+	groups := make([]string, 0, rv.Len())
+	args := make([]interface{}, 0, rv.Len()*len(group.names))
+	for i := 0; i < rv.Len(); i++ {
+		p, err := namedArgs(rv.Index(i).Interface())
+		if err != nil {
+			return "", nil, fmt.Errorf("batch element %d: %v", i, err)
+		}
+		compiled, elemArgs, err := group.bind(p)
+		if err != nil {
+			return "", nil, fmt.Errorf("batch element %d: %v", i, err)
+		}
+		groups = append(groups, compiled)
+		args = append(args, elemArgs...)
+	}
+	return prefix.parts[0] + strings.Join(groups, ",") + suffix.parts[0], args, nil
 }
@@ -0,0 +1,193 @@
+package dbx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+)
+
+// namedStmtVariants is the number of prepared statements kept by a NamedStmt for the different slice lengths.
+const namedStmtVariants = 16
+
+var errNamedStmtClosed = errors.New("named statement is closed")
+
+// NamedStmt is a prepared named query, it can be executed many times with different arguments.
+// A parameter bound to a slice is expanded to one placeholder per element,
+// so a statement is prepared for every distinct length.
+type NamedStmt struct {
+	executor *executor
+	query    string
+	syntax   namedSyntax
+
+	mu     sync.Mutex
+	closed bool
+	stmts  map[string]*namedVariant
+}
+
+// namedVariant is a prepared statement of a NamedStmt,
+// it is closed once the NamedStmt is closed and the statement is no longer used.
+type namedVariant struct {
+	stmt *Stmt
+	refs int
+}
+
+// PrepareNamed creates a prepared named statement, the statement must be closed after use.
+func (e *executor) PrepareNamed(ctx context.Context, query string) (*NamedStmt, error) {
+	ns := &NamedStmt{executor: e, query: query, syntax: e.option.namedSyntax(), stmts: map[string]*namedVariant{}}
+	t := compileNamed(query, ns.syntax)
+	p := make(map[string]interface{}, len(t.names))
+	for _, name := range t.names {
+		p[name] = nil
+	}
+	compiled, _, err := t.bind(p)
+	if err != nil {
+		return nil, err
+	}
+	stmt, err := e.PrepareContext(ctx, compiled)
+	if err != nil {
+		return nil, err
+	}
+	ns.stmts[compiled] = &namedVariant{stmt: stmt}
+	return ns, nil
+}
+
+// stmt returns the statement for arg and the arguments in the order of the first compilation.
+// The statement must be released by the returned function, Close does not close it before.
+func (s *NamedStmt) stmt(ctx context.Context, arg interface{}) (*Stmt, []interface{}, func(), error) {
+	compiled, args, err := namedCompileSyntax(s.query, arg, s.syntax)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if v := s.acquire(compiled); v != nil {
+		return v.stmt, args, func() { s.release(v) }, nil
+	}
+	if s.isClosed() {
+		return nil, nil, nil, errNamedStmtClosed
+	}
+	// the statement is prepared without holding the lock, so the other variants are not blocked meanwhile.
+	stmt, err := s.executor.PrepareContext(ctx, compiled)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		_ = stmt.Close()
+		return nil, nil, nil, errNamedStmtClosed
+	}
+	if v, ok := s.stmts[compiled]; ok {
+		// prepared concurrently by another call.
+		v.refs++
+		s.mu.Unlock()
+		_ = stmt.Close()
+		return v.stmt, args, func() { s.release(v) }, nil
+	}
+	if len(s.stmts) >= namedStmtVariants {
+		s.mu.Unlock()
+		return stmt, args, func() { _ = stmt.Close() }, nil
+	}
+	v := &namedVariant{stmt: stmt, refs: 1}
+	s.stmts[compiled] = v
+	s.mu.Unlock()
+	return stmt, args, func() { s.release(v) }, nil
+}
+
+// acquire returns the prepared variant of compiled, nil if there is none or the statement is closed.
+func (s *NamedStmt) acquire(compiled string) *namedVariant {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.stmts[compiled]
+	if !ok || s.closed {
+		return nil
+	}
+	v.refs++
+	return v
+}
+
+// release gives back a variant returned by acquire, it is closed if the NamedStmt has been closed meanwhile.
+func (s *NamedStmt) release(v *namedVariant) {
+	s.mu.Lock()
+	v.refs--
+	closable := s.closed && v.refs == 0
+	s.mu.Unlock()
+	if closable {
+		_ = v.stmt.Close()
+	}
+}
+
+func (s *NamedStmt) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+// ExecContext executes the statement with arg without returning any rows.
+// arg is a map with string keys, a struct or a slice of them like NamedExec.
+func (s *NamedStmt) ExecContext(ctx context.Context, arg interface{}) (sql.Result, error) {
+	stmt, args, release, err := s.stmt(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return stmt.ExecContext(ctx, args...)
+}
+
+// Exec executes the statement with arg without returning any rows.
+func (s *NamedStmt) Exec(arg interface{}) (sql.Result, error) {
+	return s.ExecContext(context.Background(), arg)
+}
+
+// GetContext executes the statement with arg and scans the first row to dest like Get.
+func (s *NamedStmt) GetContext(ctx context.Context, dest interface{}, arg interface{}) error {
+	stmt, args, release, err := s.stmt(ctx, arg)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return stmt.GetContext(ctx, dest, args...)
+}
+
+// Get executes the statement with arg and scans the first row to dest like Get.
+func (s *NamedStmt) Get(dest interface{}, arg interface{}) error {
+	return s.GetContext(context.Background(), dest, arg)
+}
+
+// QueryContext executes the statement with arg and scans the rows to dest like Query.
+func (s *NamedStmt) QueryContext(ctx context.Context, dest interface{}, arg interface{}) error {
+	stmt, args, release, err := s.stmt(ctx, arg)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return stmt.QueryContext(ctx, dest, args...)
+}
+
+// Query executes the statement with arg and scans the rows to dest like Query.
+func (s *NamedStmt) Query(dest interface{}, arg interface{}) error {
+	return s.QueryContext(context.Background(), dest, arg)
+}
+
+// Close closes all prepared statements, the ones in use are closed when their calls return.
+func (s *NamedStmt) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	var unused []*Stmt
+	for _, v := range s.stmts {
+		if v.refs == 0 {
+			unused = append(unused, v.stmt)
+		}
+	}
+	s.mu.Unlock()
+	var err error
+	for _, stmt := range unused {
+		if e := stmt.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
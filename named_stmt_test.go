@@ -0,0 +1,78 @@
+package dbx
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNamedStmt(t *testing.T) {
+	db := openSQLite(t)
+	ctx := context.Background()
+	insert, err := db.PrepareNamed(ctx, "insert into accounts(nickname, status) values(:nickname, :status)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer insert.Close()
+	for i, name := range []string{"jack", "lucy", "tom"} {
+		if _, err = insert.Exec(map[string]interface{}{"nickname": name, "status": i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = insert.Exec(&namedAccount{NickName: "lily"}); err != nil {
+		t.Fatal(err)
+	}
+
+	query, err := db.PrepareNamed(ctx, "select nickname from accounts where nickname in (:names) order by id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer query.Close()
+	var names []string
+	if err = query.Query(&names, map[string]interface{}{"names": []string{"jack", "tom"}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 || names[1] != "tom" {
+		t.Fatalf("unexpected names:%v", names)
+	}
+	var name string
+	if err = query.Get(&name, map[string]interface{}{"names": []string{"lily"}}); err != nil || name != "lily" {
+		t.Fatalf("unexpected name:%v err:%v", name, err)
+	}
+	if len(query.stmts) != 2 {
+		t.Fatalf("expected a statement per slice length, got %d", len(query.stmts))
+	}
+
+	if _, err = db.PrepareNamed(ctx, "select x from accounts where id=:id"); err == nil {
+		t.Fatal("expected prepare error")
+	}
+	_ = insert.Close()
+	if _, err = insert.Exec(map[string]interface{}{"nickname": "x", "status": 1}); err != errNamedStmtClosed {
+		t.Fatalf("expected closed error, got:%v", err)
+	}
+}
+
+func TestNamedStmt_CloseWhileInUse(t *testing.T) {
+	db := openSQLite(t)
+	ctx := context.Background()
+	ns, err := db.PrepareNamed(ctx, "insert into accounts(nickname, status) values(:nickname, :status)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmt, args, release, err := ns.stmt(ctx, map[string]interface{}{"nickname": "jack", "status": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ns.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = stmt.ExecContext(ctx, args...); err != nil {
+		t.Fatalf("a statement in use must not be closed:%v", err)
+	}
+	release()
+	if _, err = stmt.ExecContext(ctx, args...); err == nil {
+		t.Fatal("expected the released statement to be closed")
+	}
+	if _, err = ns.Exec(map[string]interface{}{"nickname": "x", "status": 1}); err != errNamedStmtClosed {
+		t.Fatalf("expected closed error, got:%v", err)
+	}
+}
@@ -19,3 +19,129 @@ func Test_compile(t *testing.T) {
 	}
 	fmt.Printf("SQL:%v args:%v", cq, ca)
 }
+
+type namedAudit struct {
+	CreatedBy string `dbx:"column:created_by"`
+}
+
+type namedAccount struct {
+	namedAudit
+	NickName string `dbx:"column:nickname"`
+	Status   *int   `dbx:"column:status"`
+	Network  struct {
+		ID int64 `dbx:"column:network_id"`
+	}
+}
+
+func Test_compileStruct(t *testing.T) {
+	status := 2
+	account := namedAccount{NickName: "jack", Status: &status}
+	account.CreatedBy = "admin"
+	account.Network.ID = 9
+	query, args, err := namedCompile("update accounts set nickname=:nickname, status=:status where created_by=:created_by and network_id=:network_id", account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "update accounts set nickname=?, status=? where created_by=? and network_id=?" {
+		t.Fatalf("unexpected query:%v", query)
+	}
+	if fmt.Sprint(args) != "[jack 2 admin 9]" {
+		t.Fatalf("unexpected args:%v", args)
+	}
+
+	_, args, err = namedCompile("select * from accounts where status=:status", &namedAccount{})
+	if err != nil || len(args) != 1 || args[0] != nil {
+		t.Fatalf("unexpected args:%v err:%v", args, err)
+	}
+}
+
+func Test_compileBatch(t *testing.T) {
+	accounts := []*namedAccount{{NickName: "jack"}, {NickName: "lucy"}}
+	query, args, err := namedCompile("insert into accounts(nickname, created_by) values (:nickname, lower(:created_by)) on conflict do nothing", accounts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "insert into accounts(nickname, created_by) values (?, lower(?)),(?, lower(?)) on conflict do nothing" {
+		t.Fatalf("unexpected query:%v", query)
+	}
+	if len(args) != 4 || args[0] != "jack" || args[2] != "lucy" {
+		t.Fatalf("unexpected args:%v", args)
+	}
+	_, _, err = namedCompile("update accounts set status=:status", accounts)
+	if err == nil {
+		t.Fatal("expected error without VALUES")
+	}
+}
+
+func TestExecutor_NamedExecStruct(t *testing.T) {
+	db := openSQLite(t)
+	rs, err := db.NamedExec("insert into accounts(nickname, status) values(:nickname, :status)", []namedAccount{
+		{NickName: "jack"}, {NickName: "lucy"}, {NickName: "tom"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := rs.RowsAffected(); n != 3 {
+		t.Fatalf("expected 3 rows, got %d", n)
+	}
+	var names []string
+	db.MustNamedQuery(&names, "select nickname from accounts where nickname in (:names) order by id", map[string][]string{
+		"names": {"jack", "tom"},
+	})
+	if len(names) != 2 || names[1] != "tom" {
+		t.Fatalf("unexpected names:%v", names)
+	}
+}
+
+func Test_lexNamed(t *testing.T) {
+	p := map[string]interface{}{"id": 1, "identity": 2, "name": "jack"}
+	cases := []struct {
+		query  string
+		syntax namedSyntax
+		expect string
+		args   int
+	}{
+		{"select * from t where id=:identity or id=:id", defaultNamedSyntax, "select * from t where id=? or id=?", 2},
+		{"select ':id', \":id\", `:id` from t where id=:id", defaultNamedSyntax, "select ':id', \":id\", `:id` from t where id=?", 1},
+		{"select 'it''s :id', 'a\\':id' from t where id=:id", defaultNamedSyntax, "select 'it''s :id', 'a\\':id' from t where id=?", 1},
+		{"select 'a\\' from t where id=:id", namedSyntax{style: NamedColon}, "select 'a\\' from t where id=?", 1},
+		{"select id::text from t -- :id\nwhere id=:id /* :name */", defaultNamedSyntax, "select id::text from t -- :id\nwhere id=? /* :name */", 1},
+		{"select '10\\:30', 10\\:30 from t # :id", defaultNamedSyntax, "select '10\\:30', 10:30 from t # :id", 0},
+		{"select @@version, @name, $1, $name, :id", namedSyntax{style: NamedAt | NamedDollar}, "select @@version, ?, $1, ?, :id", 2},
+		{"select $$ :id $$, $body$ :name $body$, data #>> '{a}' from t where id=:id", namedSyntax{style: NamedColon},
+			"select $$ :id $$, $body$ :name $body$, data #>> '{a}' from t where id=?", 1},
+		{"select $name$ x $name$, $name, $1", namedSyntax{style: NamedDollar}, "select $name$ x $name$, ?, $1", 1},
+		{"select $$ :id $$ from t where id=:id", defaultNamedSyntax, "select $$ ? $$ from t where id=?", 2},
+	}
+	for _, c := range cases {
+		query, args, err := compileNamed(c.query, c.syntax).bind(p)
+		if err != nil {
+			t.Fatalf("%s: %v", c.query, err)
+		}
+		if query != c.expect || len(args) != c.args {
+			t.Fatalf("%s: got %s %v", c.query, query, args)
+		}
+	}
+}
+
+func TestOptions_namedSyntax(t *testing.T) {
+	for _, c := range []struct {
+		dialect Dialect
+		mysql   bool
+	}{{MySQL, true}, {SQLite, false}, {nil, false}} {
+		if syntax := (&Options{Dialect: c.dialect}).namedSyntax(); syntax.mysql != c.mysql {
+			t.Errorf("dialect %v: mysql=%v", c.dialect, syntax.mysql)
+		}
+	}
+}
+
+func Test_lexNamedValues(t *testing.T) {
+	tpl := compileNamed("insert into t(a, b) select 'values (' from x; insert into t values (:a, f(:b))", defaultNamedSyntax)
+	if tpl.valuesOpen < 0 || tpl.valuesClose < 0 {
+		t.Fatal("VALUES list not found")
+	}
+	query := "insert into t(a, b) select 'values (' from x; insert into t values (:a, f(:b))"
+	if query[tpl.valuesOpen:tpl.valuesClose+1] != "(:a, f(:b))" {
+		t.Fatalf("unexpected VALUES list:%s", query[tpl.valuesOpen:tpl.valuesClose+1])
+	}
+}
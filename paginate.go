@@ -0,0 +1,77 @@
+package dbx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Page describes the page returned by Paginate.
+type Page struct {
+	// Total is the number of rows selected by the query without pagination.
+	Total int64 `json:"total"`
+	// Page is the 1-based page number.
+	Page int `json:"page"`
+	// Size is the maximum number of rows in a page.
+	Size int `json:"size"`
+	// Pages is the number of pages.
+	Pages int `json:"pages"`
+}
+
+// countSQL wraps query to count its rows.
+func countSQL(query string) string {
+	return "select count(*) from (" + query + ") as dbx_count"
+}
+
+// limitOffset returns the LIMIT/OFFSET clause of the dialect of options.
+func (o *Options) limitOffset(limit, offset int) string {
+	if o.Dialect != nil {
+		return o.Dialect.LimitOffset(limit, offset)
+	}
+	return fmt.Sprintf(" limit %d offset %d", limit, offset)
+}
+
+func (mysqlDialect) LimitOffset(limit, offset int) string {
+	return fmt.Sprintf(" limit %d offset %d", limit, offset)
+}
+
+func (sqliteDialect) LimitOffset(limit, offset int) string {
+	return fmt.Sprintf(" limit %d offset %d", limit, offset)
+}
+
+// Paginate scans the rows of the 1-based page to dest, dest must be a pointer to slice like Query.
+// The total is counted by wrapping query in select count(*) from (query),
+// and the page is selected by appending a LIMIT/OFFSET clause to query.
+func (e *executor) Paginate(ctx context.Context, dest interface{}, query string, page, size int, args ...interface{}) (Page, error) {
+	if size <= 0 {
+		return Page{}, errors.New("page size must be greater than 0")
+	}
+	if page < 1 {
+		page = 1
+	}
+	p := Page{Page: page, Size: size}
+	// the Preload options apply to the page only.
+	countArgs, _ := splitPreload(args)
+	err := e.GetContext(ctx, &p.Total, countSQL(query), countArgs...)
+	if err != nil {
+		return p, err
+	}
+	p.Pages = int((p.Total + int64(size) - 1) / int64(size))
+	return p, e.QueryContext(ctx, dest, query+e.option.limitOffset(size, (page-1)*size), args...)
+}
+
+// PaginateTx is the same as Paginate, but counts and selects the page in one transaction,
+// so that the total is consistent with the page.
+func (d *DB) PaginateTx(ctx context.Context, opts *sql.TxOptions, dest interface{}, query string, page, size int, args ...interface{}) (p Page, err error) {
+	tx, err := d.BeginTx(ctx, opts)
+	if err != nil {
+		return
+	}
+	p, err = tx.Paginate(ctx, dest, query, page, size, args...)
+	if err != nil {
+		_ = tx.Rollback()
+		return
+	}
+	return p, tx.Commit()
+}
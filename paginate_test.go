@@ -0,0 +1,51 @@
+package dbx
+
+import (
+	"context"
+	"testing"
+)
+
+func TestExecutor_Paginate(t *testing.T) {
+	db := openSQLite(t)
+	for i := 0; i < 7; i++ {
+		db.MustInsert(&accountRecord{Status: i % 2})
+	}
+	var accounts []*accountRecord
+	page, err := db.Paginate(context.Background(), &accounts, "select * from accounts where status=? order by id", 2, 3, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page != (Page{Total: 4, Page: 2, Size: 3, Pages: 2}) {
+		t.Fatalf("unexpected page:%+v", page)
+	}
+	if len(accounts) != 1 || accounts[0].ID != 7 {
+		t.Fatalf("unexpected accounts:%v", accounts)
+	}
+
+	var ids []int64
+	page, err = db.PaginateTx(context.Background(), nil, &ids, "select id from accounts order by id desc", 1, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 7 || page.Pages != 2 || len(ids) != 5 || ids[0] != 7 {
+		t.Fatalf("unexpected page:%+v ids:%v", page, ids)
+	}
+
+	db.MustExec("create table users(id integer primary key autoincrement, name text, network_id integer)")
+	db.MustExec("create table profiles(id integer primary key autoincrement, user_id integer, bio text)")
+	db.MustExec("create table networks(id integer primary key, name text)")
+	db.MustExec("insert into users(name) values('jack'), ('lucy'), ('tom')")
+	db.MustExec("update accounts set uid=2 where id<=3")
+	var users []*relationUser
+	page, err = db.Paginate(context.Background(), &users, "select id, name, network_id from users where id>? order by id", 1, 2, 0, Preload("Accounts"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 3 || len(users) != 2 || users[0].ID != 1 || len(users[0].Accounts) != 0 || len(users[1].Accounts) != 3 {
+		t.Fatalf("unexpected page:%+v users:%v", page, users)
+	}
+
+	if _, err = db.Paginate(context.Background(), &ids, "select id from accounts", 1, 0); err == nil {
+		t.Fatal("expected error for empty page size")
+	}
+}
@@ -13,9 +13,11 @@ type nullable interface {
 	Value() (driver.Value, error)
 }
 
-func printSQL(query string, args []interface{}, opts *Options) {
+// printSQL writes the statement of e to the Logger of opts with its arguments inlined, like it always did:
+// the duration, the caller and the error are sent to the QueryLogger only.
+func printSQL(e *QueryEvent, opts *Options) {
 	if opts.Logger != nil {
-		opts.Logger.Printf(formatSQL(query, args, opts))
+		opts.Logger.Printf(formatSQL(e.SQL, e.Args, opts))
 	}
 }
 
@@ -68,9 +68,12 @@ func ReflectProperty(v reflect.Value, mapping map[string]Property) {
 		fv := direct.Field(i)
 		ft := dt.Field(i)
 		tag := newDbxTag(ft.Tag.Get("dbx"))
+		if tag.IsRelation() {
+			continue
+		}
 
 		if ft.Type.Kind() == reflect.Ptr {
-			if IsStructType(ft.Type.Elem()) {
+			if IsStructType(ft.Type.Elem()) && !fv.IsNil() {
 				ReflectProperty(fv, mapping)
 			}
 		} else {
@@ -89,6 +92,46 @@ func ReflectProperty(v reflect.Value, mapping map[string]Property) {
 	}
 }
 
+//Field is a column of a struct type
+type Field struct {
+	Type reflect.Type
+	Tag  *Tag
+}
+
+//TypeFields returns the columns of the struct type t in the order of the fields,
+//the columns of the nested structs are flattened like ReflectProperty
+func TypeFields(t reflect.Type) []Field {
+	var fields []Field
+	index := map[string]int{}
+	typeFields(t, &fields, index)
+	return fields
+}
+
+func typeFields(t reflect.Type, fields *[]Field, index map[string]int) {
+	for i := 0; i < t.NumField(); i++ {
+		ft := t.Field(i)
+		tag := newDbxTag(ft.Tag.Get("dbx"))
+		if tag.IsRelation() {
+			continue
+		}
+		st := ft.Type
+		if st.Kind() == reflect.Ptr {
+			st = st.Elem()
+		}
+		if IsStructType(st) {
+			typeFields(st, fields, index)
+		}
+		if tag.Column == "" {
+			continue
+		}
+		if j, ok := index[tag.Column]; ok {
+			(*fields)[j] = Field{Type: ft.Type, Tag: tag}
+			continue
+		}
+		index[tag.Column] = len(*fields)
+		*fields = append(*fields, Field{Type: ft.Type, Tag: tag})
+	}
+}
 
 func indirectPtr(dest interface{}) (reflect.Value, error) {
 	value := reflect.ValueOf(dest)
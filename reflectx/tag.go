@@ -2,6 +2,7 @@ package reflectx
 
 import (
 	"reflect"
+	"strconv"
 	"strings"
 )
 
@@ -37,6 +38,36 @@ type Tag struct {
 	Insert        string
 	Update        string
 	AutoIncrement bool
+	// ShardKey marks the field routing the struct to a shard of a ShardedDB.
+	ShardKey bool
+	// Type is the column type used by AutoMigrate instead of the type mapped from the field type.
+	Type string
+	// Size is the length of a varchar column.
+	Size int
+	// NotNull declares the column NOT NULL.
+	NotNull bool
+	// Default is the DEFAULT expression of the column.
+	Default string
+	// Index creates an index on the column.
+	Index bool
+	// Unique creates an unique index on the column.
+	Unique bool
+	// HasMany is the column of the related table referencing the primary key, the field is a slice.
+	HasMany string
+	// HasOne is the column of the related table referencing the primary key.
+	HasOne string
+	// BelongsTo is the column referencing the primary key of the related table.
+	BelongsTo string
+}
+
+//IsRelation reports whether the field is a relation instead of a column
+func (t *Tag) IsRelation() bool {
+	return t.HasMany != "" || t.HasOne != "" || t.BelongsTo != ""
+}
+
+//ParseTag parses a `dbx` tag
+func ParseTag(tag string) *Tag {
+	return newDbxTag(tag)
 }
 
 func newDbxTag(tag string) *Tag {
@@ -55,6 +86,24 @@ func newDbxTag(tag string) *Tag {
 			if propName == "insert" {
 				t.Insert = strings.TrimSpace(prop[splitIdx+1:])
 			}
+			if propName == "type" {
+				t.Type = strings.TrimSpace(prop[splitIdx+1:])
+			}
+			if propName == "size" {
+				t.Size, _ = strconv.Atoi(strings.TrimSpace(prop[splitIdx+1:]))
+			}
+			if propName == "default" {
+				t.Default = strings.TrimSpace(prop[splitIdx+1:])
+			}
+			if propName == "has_many" {
+				t.HasMany = strings.TrimSpace(prop[splitIdx+1:])
+			}
+			if propName == "has_one" {
+				t.HasOne = strings.TrimSpace(prop[splitIdx+1:])
+			}
+			if propName == "belongs_to" {
+				t.BelongsTo = strings.TrimSpace(prop[splitIdx+1:])
+			}
 		} else {
 			if strings.TrimSpace(prop) == "primary_key" {
 				t.PrimaryKey = true
@@ -62,6 +111,18 @@ func newDbxTag(tag string) *Tag {
 			if strings.TrimSpace(prop) == "auto_increment" {
 				t.AutoIncrement = true
 			}
+			if strings.TrimSpace(prop) == "shard_key" {
+				t.ShardKey = true
+			}
+			if strings.TrimSpace(prop) == "not_null" {
+				t.NotNull = true
+			}
+			if strings.TrimSpace(prop) == "index" {
+				t.Index = true
+			}
+			if strings.TrimSpace(prop) == "unique" {
+				t.Unique = true
+			}
 		}
 	}
 	return t
@@ -0,0 +1,276 @@
+package dbx
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/microbun/dbx/reflectx"
+)
+
+// PreloadChunkSize is the maximum number of keys in the IN list of a preload query.
+var PreloadChunkSize = 500
+
+type relationKind int
+
+const (
+	hasMany relationKind = iota
+	hasOne
+	belongsTo
+)
+
+// relation is a field tagged with has_many, has_one or belongs_to.
+type relation struct {
+	kind  relationKind
+	field reflect.StructField
+	// column is the foreign key column, it is in the related table for has_many and has_one.
+	column string
+	// elem is the struct type of the related rows.
+	elem reflect.Type
+}
+
+func parseRelation(parent reflect.Type, name string) (*relation, error) {
+	field, ok := parent.FieldByName(name)
+	if !ok {
+		return nil, fmt.Errorf("missing field `%s` in %s", name, parent)
+	}
+	tag := reflectx.ParseTag(field.Tag.Get("dbx"))
+	r := &relation{field: field}
+	switch {
+	case tag.HasMany != "":
+		r.kind, r.column = hasMany, tag.HasMany
+	case tag.HasOne != "":
+		r.kind, r.column = hasOne, tag.HasOne
+	case tag.BelongsTo != "":
+		r.kind, r.column = belongsTo, tag.BelongsTo
+	default:
+		return nil, fmt.Errorf("field `%s` in %s is not a relation", name, parent)
+	}
+	elem := field.Type
+	if r.kind == hasMany {
+		if elem.Kind() != reflect.Slice {
+			return nil, fmt.Errorf("has_many field `%s` in %s must be a slice", name, parent)
+		}
+		elem = elem.Elem()
+	}
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	if !reflectx.IsStructType(elem) {
+		return nil, fmt.Errorf("relation field `%s` in %s must be a struct", name, parent)
+	}
+	r.elem = elem
+	return r, nil
+}
+
+// relationKey normalizes a key value, so that the keys of different Go types like int32, int64 and
+// sql.NullInt64 can be compared. ok is false for null keys.
+func relationKey(v reflect.Value) (key string, ok bool) {
+	iv := v.Interface()
+	if valuer, isValuer := iv.(driver.Valuer); isValuer {
+		dv, err := valuer.Value()
+		if err != nil || dv == nil {
+			return "", false
+		}
+		iv = dv
+	}
+	rv := reflectx.ActualValue(reflect.ValueOf(iv))
+	if !rv.IsValid() {
+		return "", false
+	}
+	if b, isBytes := rv.Interface().([]byte); isBytes {
+		return string(b), true
+	}
+	return fmt.Sprint(rv.Interface()), true
+}
+
+// columnValue returns the field of column in the struct v.
+func columnValue(v reflect.Value, column string) (reflect.Value, error) {
+	props := map[string]reflectx.Property{}
+	reflectx.ReflectProperty(v, props)
+	prop, ok := props[column]
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("missing field `%s` in %s", column, v.Type())
+	}
+	return *prop.Value, nil
+}
+
+// primaryKey returns the primary key column of the struct type t.
+func primaryKey(t reflect.Type) (string, error) {
+	props := map[string]reflectx.Property{}
+	reflectx.ReflectProperty(reflect.New(t), props)
+	for column, prop := range props {
+		if prop.Tag.PrimaryKey {
+			return column, nil
+		}
+	}
+	return "", fmt.Errorf("missing primary key in %s", t)
+}
+
+// PreloadOption is an argument of Get and Query loading relations of the scanned structs, see Preload.
+type PreloadOption struct {
+	relations []string
+}
+
+// Preload returns an argument of Get and Query which loads the relations of dest after scanning it
+// like PreloadContext, for example:
+//
+//	db.Query(&users, "select * from users where status=?", 1, dbx.Preload("Accounts"))
+func Preload(relations ...string) PreloadOption {
+	return PreloadOption{relations: relations}
+}
+
+// splitPreload removes the PreloadOption arguments from args and returns their relations.
+func splitPreload(args []interface{}) ([]interface{}, []string) {
+	rest := args
+	var relations []string
+	for i := len(args) - 1; i >= 0; i-- {
+		if opt, ok := args[i].(PreloadOption); ok {
+			if len(rest) == len(args) {
+				rest = append([]interface{}{}, args...)
+			}
+			rest = append(rest[:i], rest[i+1:]...)
+			relations = append(append([]string{}, opt.relations...), relations...)
+		}
+	}
+	return rest, relations
+}
+
+// PreloadContext loads the relations of dest, dest is a pointer to a struct or to a slice of structs
+// which are the result of Get or Query. A relation is a field tagged with:
+//
+//	has_many:<column>    a slice of the rows whose column references the primary key of the struct
+//	has_one:<column>     the row whose column references the primary key of the struct
+//	belongs_to:<column>  the row referenced by the column of the struct
+//
+// Every relation is loaded by one query with a IN list of keys per PreloadChunkSize structs.
+func (e *executor) PreloadContext(ctx context.Context, dest interface{}, relations ...string) error {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr {
+		return errors.New("dest must be a ptr")
+	}
+	rv = rv.Elem()
+	var parents []reflect.Value
+	if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		for i := 0; i < rv.Len(); i++ {
+			if v := rv.Index(i); v.Kind() != reflect.Ptr || !v.IsNil() {
+				parents = append(parents, reflect.Indirect(v))
+			}
+		}
+	} else {
+		parents = append(parents, rv)
+	}
+	t := rv.Type()
+	if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if !reflectx.IsStructType(t) {
+		return errors.New("dest must be a struct or a slice of struct")
+	}
+	for _, name := range relations {
+		r, err := parseRelation(t, name)
+		if err != nil {
+			return err
+		}
+		if err = e.preload(ctx, parents, t, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Preload loads the relations of dest, see PreloadContext.
+func (e *executor) Preload(dest interface{}, relations ...string) error {
+	return e.PreloadContext(context.Background(), dest, relations...)
+}
+
+func (e *executor) preload(ctx context.Context, parents []reflect.Value, parentType reflect.Type, r *relation) error {
+	// parentColumn is the column of the parents and childColumn is the column of the related rows matching it.
+	parentColumn, childColumn := "", r.column
+	var err error
+	if r.kind == belongsTo {
+		parentColumn = r.column
+		childColumn, err = primaryKey(r.elem)
+	} else {
+		parentColumn, err = primaryKey(parentType)
+	}
+	if err != nil {
+		return err
+	}
+	table := e.option.tableOf(ctx, reflect.New(r.elem).Interface())
+	if table == "" {
+		return fmt.Errorf("%s not implement Table interface", r.elem)
+	}
+
+	byKey := map[string][]reflect.Value{}
+	var keys []interface{}
+	for _, parent := range parents {
+		// the relations loaded before are reset, the parents may be reused.
+		field := parent.FieldByIndex(r.field.Index)
+		field.Set(reflect.Zero(field.Type()))
+		fv, err := columnValue(parent, parentColumn)
+		if err != nil {
+			return err
+		}
+		key, ok := relationKey(fv)
+		if !ok {
+			continue
+		}
+		if _, exists := byKey[key]; !exists {
+			keys = append(keys, fv.Interface())
+		}
+		byKey[key] = append(byKey[key], parent)
+	}
+
+	for start := 0; start < len(keys); start += PreloadChunkSize {
+		end := start + PreloadChunkSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+		chunk := keys[start:end]
+		rows := reflect.New(reflect.SliceOf(reflect.PtrTo(r.elem)))
+		query := fmt.Sprintf("select * from %s where %s in (%s)", e.option.quoteTable(table), childColumn,
+			strings.TrimSuffix(strings.Repeat("?,", len(chunk)), ","))
+		if err := e.QueryContext(withTable(ctx, table), rows.Interface(), query, chunk...); err != nil {
+			return err
+		}
+		rows = rows.Elem()
+		for i := 0; i < rows.Len(); i++ {
+			row := rows.Index(i)
+			cv, err := columnValue(row.Elem(), childColumn)
+			if err != nil {
+				return err
+			}
+			key, ok := relationKey(cv)
+			if !ok {
+				continue
+			}
+			for _, parent := range byKey[key] {
+				assignRelation(parent.FieldByIndex(r.field.Index), row, r.kind)
+			}
+		}
+	}
+	return nil
+}
+
+// assignRelation assigns the related row, a pointer to struct, to the relation field.
+func assignRelation(field reflect.Value, row reflect.Value, kind relationKind) {
+	if kind == hasMany {
+		if field.Type().Elem().Kind() != reflect.Ptr {
+			row = row.Elem()
+		}
+		field.Set(reflect.Append(field, row))
+		return
+	}
+	if field.Kind() == reflect.Ptr {
+		field.Set(row)
+	} else {
+		field.Set(row.Elem())
+	}
+}
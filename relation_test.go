@@ -0,0 +1,110 @@
+package dbx
+
+import (
+	"database/sql"
+	"testing"
+)
+
+type relationUser struct {
+	ID       int64              `dbx:"column:id;primary_key;auto_increment"`
+	Name     string             `dbx:"column:name"`
+	Accounts []*relationAccount `dbx:"has_many:uid"`
+	Profile  *relationProfile   `dbx:"has_one:user_id"`
+	Network  relationNetwork    `dbx:"belongs_to:network_id"`
+	Others   []relationAccount  `dbx:"has_many:uid"`
+	NetID    *int64             `dbx:"column:network_id"`
+}
+
+func (*relationUser) TableName() string {
+	return "users"
+}
+
+type relationAccount struct {
+	accountRecord
+	Network *relationNetwork `dbx:"belongs_to:network_id"`
+}
+
+type relationProfile struct {
+	ID     int64  `dbx:"column:id;primary_key;auto_increment"`
+	UserID int64  `dbx:"column:user_id"`
+	Bio    string `dbx:"column:bio"`
+}
+
+func (*relationProfile) TableName() string {
+	return "profiles"
+}
+
+type relationNetwork struct {
+	ID   int32  `dbx:"column:id;primary_key"`
+	Name string `dbx:"column:name"`
+}
+
+func (*relationNetwork) TableName() string {
+	return "networks"
+}
+
+func TestExecutor_Preload(t *testing.T) {
+	db := openSQLite(t)
+	db.MustExec("create table users(id integer primary key autoincrement, name text, network_id integer)")
+	db.MustExec("create table profiles(id integer primary key autoincrement, user_id integer, bio text)")
+	db.MustExec("create table networks(id integer primary key, name text)")
+	db.MustExec("insert into networks(id, name) values(1, 'wifi'), (2, 'lte')")
+	db.MustExec("insert into users(name, network_id) values('jack', 1), ('lucy', 2), ('tom', null)")
+	db.MustExec("insert into profiles(user_id, bio) values(2, 'hello')")
+	for _, a := range [][2]int64{{1, 2}, {1, 1}, {2, 1}} {
+		db.MustInsert(&accountRecord{UID: sql.NullInt64{Int64: a[0], Valid: true}, NetworkID: sql.NullInt64{Int64: a[1], Valid: true}})
+	}
+
+	PreloadChunkSize = 2
+	defer func() {
+		PreloadChunkSize = 500
+	}()
+	var users []*relationUser
+	db.MustQuery(&users, "select id, name, network_id from users order by id")
+	err := db.Preload(&users, "Accounts", "Profile", "Network", "Others")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users[0].Accounts) != 2 || len(users[1].Accounts) != 1 || len(users[2].Accounts) != 0 {
+		t.Fatalf("unexpected accounts:%v %v %v", users[0].Accounts, users[1].Accounts, users[2].Accounts)
+	}
+	if len(users[0].Others) != 2 {
+		t.Fatalf("unexpected others:%v", users[0].Others)
+	}
+	if users[0].Profile != nil || users[1].Profile == nil || users[1].Profile.Bio != "hello" {
+		t.Fatalf("unexpected profiles:%v %v", users[0].Profile, users[1].Profile)
+	}
+	if users[0].Network.Name != "wifi" || users[1].Network.Name != "lte" || users[2].Network.Name != "" {
+		t.Fatalf("unexpected networks:%v %v %v", users[0].Network, users[1].Network, users[2].Network)
+	}
+
+	if err = db.Preload(*users[0].Accounts[0], "Network"); err == nil {
+		t.Fatal("expected error for a non pointer dest")
+	}
+	account := users[0].Accounts[0]
+	if err = db.Preload(account, "Network"); err != nil || account.Network == nil || account.Network.Name != "lte" {
+		t.Fatalf("unexpected network:%v %v", account.Network, err)
+	}
+	if err = db.Preload(&users, "Accounts"); err != nil || len(users[0].Accounts) != 2 {
+		t.Fatalf("preload must be idempotent:%v %v", users[0].Accounts, err)
+	}
+	if err = db.Preload(&users, "Name"); err == nil {
+		t.Fatal("expected error for a column")
+	}
+
+	db.MustExec("delete from profiles")
+	users[0].NetID = nil
+	if err = db.Preload(&users, "Profile", "Network"); err != nil || users[1].Profile != nil || users[0].Network.Name != "" {
+		t.Fatalf("stale relations must be reset:%v %v %v", users[1].Profile, users[0].Network, err)
+	}
+
+	var reloaded []*relationUser
+	err = db.Query(&reloaded, "select id, name, network_id from users where id<=? order by id", 2, Preload("Accounts"), Preload("Others"))
+	if err != nil || len(reloaded) != 2 || len(reloaded[0].Accounts) != 2 || len(reloaded[0].Others) != 2 {
+		t.Fatalf("unexpected query preload:%v %v", reloaded, err)
+	}
+	user := &relationUser{}
+	if err = db.Get(user, "select id, name, network_id from users where id=?", 2, Preload("Accounts")); err != nil || len(user.Accounts) != 1 {
+		t.Fatalf("unexpected get preload:%v %v", user.Accounts, err)
+	}
+}
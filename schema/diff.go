@@ -0,0 +1,179 @@
+// Package schema compares the tables declared by the dbx tags of models with the live database.
+package schema
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/microbun/dbx"
+	"github.com/microbun/dbx/introspect"
+	"github.com/microbun/dbx/migrate"
+)
+
+// Kind is the kind of a Difference.
+type Kind string
+
+const (
+	// MissingTable is a table of a model which doesn't exist in the database.
+	MissingTable Kind = "missing table"
+	// ExtraTable is a table of the database which is not declared by any model.
+	ExtraTable Kind = "extra table"
+	// MissingColumn is a column of a model which doesn't exist in the database.
+	MissingColumn Kind = "missing column"
+	// ExtraColumn is a column of the database which is not declared by the model.
+	ExtraColumn Kind = "extra column"
+	// TypeMismatch is a column whose type differs from the type declared by the model.
+	TypeMismatch Kind = "type mismatch"
+	// NullabilityMismatch is a column which is nullable in the model and not in the database, or the other way.
+	NullabilityMismatch Kind = "nullability mismatch"
+	// MissingIndex is an index or unique option of a model without a matching index in the database.
+	MissingIndex Kind = "missing index"
+)
+
+// Difference is a drift between a model and the database.
+type Difference struct {
+	Kind  Kind
+	Table string
+	// Column is the column of a column difference or of a missing index.
+	Column string
+	// Index is the name declared by the model of a missing index.
+	Index string
+	// Model and Database are the types of a TypeMismatch, or null and not null for a NullabilityMismatch.
+	Model    string
+	Database string
+}
+
+func (d Difference) String() string {
+	switch d.Kind {
+	case MissingTable, ExtraTable:
+		return fmt.Sprintf("%s: %s", d.Kind, d.Table)
+	case MissingColumn, ExtraColumn:
+		return fmt.Sprintf("%s: %s.%s", d.Kind, d.Table, d.Column)
+	case MissingIndex:
+		return fmt.Sprintf("%s: %s %s(%s)", d.Kind, d.Index, d.Table, d.Column)
+	}
+	return fmt.Sprintf("%s: %s.%s is %s in the model but %s in the database", d.Kind, d.Table, d.Column, d.Model, d.Database)
+}
+
+// Report is the list of the differences found by Diff, it is empty when the database follows the models.
+type Report []Difference
+
+// String returns the differences one per line.
+func (r Report) String() string {
+	var b strings.Builder
+	for _, d := range r {
+		b.WriteString(d.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Diff compares the tables declared by models, like for dbx.DB.AutoMigrate, with the schema of db:
+// the information_schema of MySQL and the PRAGMA of SQLite.
+// The tables of the current schema which are not declared by any model are reported as ExtraTable,
+// except the internal tables of SQLite and the table of the migrate package.
+func Diff(ctx context.Context, db *dbx.DB, models ...interface{}) (Report, error) {
+	ctx = dbx.ForcePrimary(ctx)
+	var report Report
+	declared := map[string]bool{
+		migrate.DefaultTable:           true,
+		migrate.DefaultTable + "_lock": true,
+	}
+	for _, model := range models {
+		m, err := db.DescribeModel(ctx, model)
+		if err != nil {
+			return nil, err
+		}
+		declared[m.Name] = true
+		t, err := loadTable(ctx, db, m.Name)
+		if err != nil {
+			return nil, err
+		}
+		if t == nil {
+			report = append(report, Difference{Kind: MissingTable, Table: m.Name})
+			continue
+		}
+		report = append(report, diffTable(m, t)...)
+	}
+	tables, err := liveTables(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	for _, table := range tables {
+		if !declared[table] {
+			report = append(report, Difference{Kind: ExtraTable, Table: table})
+		}
+	}
+	return report, nil
+}
+
+func diffTable(m *dbx.ModelTable, t *introspect.Table) Report {
+	var report Report
+	columns := map[string]*introspect.Column{}
+	for _, c := range t.Columns {
+		columns[strings.ToLower(c.Name)] = c
+	}
+	declared := map[string]bool{}
+	for _, mc := range m.Columns {
+		declared[strings.ToLower(mc.Name)] = true
+		c, ok := columns[strings.ToLower(mc.Name)]
+		if !ok {
+			report = append(report, Difference{Kind: MissingColumn, Table: m.Name, Column: mc.Name})
+			continue
+		}
+		if normalizeType(mc.Type) != normalizeType(c.ColumnType) {
+			report = append(report, Difference{Kind: TypeMismatch, Table: m.Name, Column: mc.Name, Model: mc.Type, Database: c.ColumnType})
+		}
+		// the primary key of SQLite may be declared nullable but it is never null.
+		notNull := !c.Nullable || c.PrimaryKey
+		if mc.NotNull != notNull {
+			report = append(report, Difference{Kind: NullabilityMismatch, Table: m.Name, Column: mc.Name,
+				Model: nullability(mc.NotNull), Database: nullability(notNull)})
+		}
+	}
+	for _, c := range t.Columns {
+		if !declared[strings.ToLower(c.Name)] {
+			report = append(report, Difference{Kind: ExtraColumn, Table: m.Name, Column: c.Name})
+		}
+	}
+	for _, idx := range m.Indexes {
+		if !hasIndex(t, idx) {
+			report = append(report, Difference{Kind: MissingIndex, Table: m.Name, Column: idx.Column, Index: idx.Name})
+		}
+	}
+	return report
+}
+
+// hasIndex reports whether an index of t is on the column of idx alone and is unique if idx is unique.
+func hasIndex(t *introspect.Table, idx dbx.ModelIndex) bool {
+	if len(t.PrimaryKey) == 1 && strings.EqualFold(t.PrimaryKey[0], idx.Column) {
+		return true
+	}
+	for _, ti := range t.Indexes {
+		if len(ti.Columns) == 1 && strings.EqualFold(ti.Columns[0], idx.Column) && (ti.Unique || !idx.Unique) {
+			return true
+		}
+	}
+	return false
+}
+
+func nullability(notNull bool) string {
+	if notNull {
+		return "not null"
+	}
+	return "null"
+}
+
+var displayWidth = regexp.MustCompile(`^(smallint|mediumint|int|integer|bigint)\(\d+\)`)
+
+// normalizeType returns the lower case type without the display width of the integer types of MySQL,
+// which MySQL 5 adds to the declared types.
+func normalizeType(t string) string {
+	t = strings.Join(strings.Fields(strings.ToLower(t)), " ")
+	if strings.HasPrefix(t, "tinyint(") && !strings.HasPrefix(t, "tinyint(1)") {
+		t = "tinyint" + t[strings.Index(t, ")")+1:]
+	}
+	return displayWidth.ReplaceAllString(t, "$1")
+}
@@ -0,0 +1,100 @@
+package schema
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/microbun/dbx"
+)
+
+type user struct {
+	ID    int64  `dbx:"column:id;primary_key;auto_increment"`
+	Email string `dbx:"column:email;size:128;not_null;unique"`
+	Name  string `dbx:"column:name;size:64;index"`
+	Age   int    `dbx:"column:age;not_null"`
+	Bio   string `dbx:"column:bio"`
+}
+
+func (*user) TableName() string {
+	return "users"
+}
+
+type order struct {
+	ID int64 `dbx:"column:id;primary_key;auto_increment"`
+}
+
+func (*order) TableName() string {
+	return "orders"
+}
+
+func TestDiff(t *testing.T) {
+	ctx := context.Background()
+	db, err := dbx.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "schema.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.Options().Logger = nil
+	db.MustExec(`create table users (
+		id    integer primary key autoincrement,
+		email varchar(128) not null,
+		name  varchar(32),
+		age   integer,
+		extra text
+	)`)
+	db.MustExec("create index users_email on users(email)")
+	db.MustExec("create table audit (id integer)")
+
+	report, err := Diff(ctx, db, &user{}, &order{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Report{
+		{Kind: TypeMismatch, Table: "users", Column: "name", Model: "varchar(64)", Database: "varchar(32)"},
+		{Kind: NullabilityMismatch, Table: "users", Column: "age", Model: "not null", Database: "null"},
+		{Kind: MissingColumn, Table: "users", Column: "bio"},
+		{Kind: ExtraColumn, Table: "users", Column: "extra"},
+		{Kind: MissingIndex, Table: "users", Column: "email", Index: "uk_users_email"},
+		{Kind: MissingIndex, Table: "users", Column: "name", Index: "idx_users_name"},
+		{Kind: MissingTable, Table: "orders"},
+		{Kind: ExtraTable, Table: "audit"},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Fatalf("report:\n%s", report)
+	}
+	if s := want[0].String(); s != "type mismatch: users.name is varchar(64) in the model but varchar(32) in the database" {
+		t.Errorf("string=%s", s)
+	}
+
+	db.MustExec("drop table users")
+	db.MustExec("drop table audit")
+	if err = db.AutoMigrate(ctx, &user{}, &order{}); err != nil {
+		t.Fatal(err)
+	}
+	if report, err = Diff(ctx, db, &user{}, &order{}); err != nil || len(report) != 0 {
+		t.Fatalf("report after AutoMigrate err=%v:\n%s", err, report)
+	}
+
+	db.Options().Dialect = nil
+	if _, err = Diff(ctx, db, &user{}); err != ErrUnsupportedDialect {
+		t.Errorf("diff without dialect err=%v", err)
+	}
+}
+
+func Test_normalizeType(t *testing.T) {
+	tests := map[string]string{
+		"INT(11)":             "int",
+		"bigint(20) unsigned": "bigint unsigned",
+		"tinyint(1)":          "tinyint(1)",
+		"tinyint(4)":          "tinyint",
+		"VARCHAR(255)":        "varchar(255)",
+	}
+	for in, want := range tests {
+		if got := normalizeType(in); got != want {
+			t.Errorf("normalizeType(%s)=%s, want %s", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package schema
+
+import (
+	"context"
+
+	"github.com/microbun/dbx"
+	"github.com/microbun/dbx/introspect"
+)
+
+// ErrUnsupportedDialect is returned by Diff for a database without a dbx.Dialect, it is dbx.ErrUnsupportedDialect.
+var ErrUnsupportedDialect = dbx.ErrUnsupportedDialect
+
+func driver(db *dbx.DB) (string, error) {
+	if db.Options() == nil || db.Options().Dialect == nil {
+		return "", ErrUnsupportedDialect
+	}
+	return db.Options().Dialect.Name(), nil
+}
+
+// liveTables returns the names of the tables of the current schema, the internal tables of SQLite are excluded.
+func liveTables(ctx context.Context, db *dbx.DB) ([]string, error) {
+	d, err := driver(db)
+	if err != nil {
+		return nil, err
+	}
+	s, err := introspect.Load(ctx, db.RawDB(), d, "")
+	if err != nil {
+		return nil, err
+	}
+	var tables []string
+	for _, t := range s.Tables {
+		tables = append(tables, t.Name)
+	}
+	return tables, nil
+}
+
+// loadTable returns the columns and the indexes of table, nil if the table doesn't exist.
+func loadTable(ctx context.Context, db *dbx.DB, table string) (*introspect.Table, error) {
+	d, err := driver(db)
+	if err != nil {
+		return nil, err
+	}
+	return introspect.LoadTable(ctx, db.RawDB(), d, table)
+}
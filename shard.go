@@ -0,0 +1,376 @@
+package dbx
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"sync"
+
+	"github.com/microbun/dbx/reflectx"
+)
+
+// ErrCrossShard is returned when a sharded transaction writes a struct routed to another shard.
+var ErrCrossShard = errors.New("dbx: cross-shard transaction is not supported")
+
+// ShardFunc returns the index of the shard of key.
+// The key is a plain value: a driver.Valuer like sql.NullInt64 is replaced by its value
+// and a pointer by the value it points to, a nil or NULL key is rejected before calling ShardFunc.
+type ShardFunc func(key interface{}) int
+
+// HashShard returns a ShardFunc spreading the keys over n shards by the FNV hash of their string form.
+// It panics if n is not positive.
+func HashShard(n int) ShardFunc {
+	if n <= 0 {
+		panic(fmt.Sprintf("dbx: HashShard requires a positive number of shards, got %d", n))
+	}
+	return func(key interface{}) int {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(fmt.Sprint(key)))
+		return int(h.Sum32() % uint32(n))
+	}
+}
+
+// ShardedDB routes the operations to one of several databases holding the same tables.
+// A struct is routed by its field tagged with shard_key, a query by an explicit key.
+type ShardedDB struct {
+	shards []*DB
+	shard  ShardFunc
+}
+
+// NewShardedDB returns a ShardedDB routing the keys to shards with fn.
+func NewShardedDB(fn ShardFunc, shards ...*DB) *ShardedDB {
+	return &ShardedDB{shards: shards, shard: fn}
+}
+
+// Shards returns the databases of the shards.
+func (s *ShardedDB) Shards() []*DB {
+	return s.shards
+}
+
+// shardKey returns the plain value of key, see ShardFunc.
+func shardKey(key interface{}) (interface{}, error) {
+	for {
+		if valuer, ok := key.(driver.Valuer); ok {
+			if v := reflect.ValueOf(key); v.Kind() == reflect.Ptr && v.IsNil() {
+				return nil, errors.New("shard key is nil")
+			}
+			value, err := valuer.Value()
+			if err != nil {
+				return nil, err
+			}
+			key = value
+			continue
+		}
+		v := reflect.ValueOf(key)
+		if !v.IsValid() || v.Kind() == reflect.Ptr && v.IsNil() {
+			return nil, errors.New("shard key is nil")
+		}
+		if v.Kind() != reflect.Ptr {
+			return key, nil
+		}
+		key = v.Elem().Interface()
+	}
+}
+
+func (s *ShardedDB) index(key interface{}) (int, error) {
+	key, err := shardKey(key)
+	if err != nil {
+		return 0, err
+	}
+	i := s.shard(key)
+	if i < 0 || i >= len(s.shards) {
+		return 0, fmt.Errorf("shard %d of key %v out of range [0,%d)", i, key, len(s.shards))
+	}
+	return i, nil
+}
+
+// indexOf returns the index of the shard of the struct value.
+func (s *ShardedDB) indexOf(value interface{}) (int, error) {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Ptr || !reflectx.IsStructValue(reflect.Indirect(v)) {
+		return 0, errors.New("value must be a pointer to struct")
+	}
+	props := map[string]reflectx.Property{}
+	reflectx.ReflectProperty(v, props)
+	for _, prop := range props {
+		if prop.Tag.ShardKey {
+			return s.index(prop.Value.Interface())
+		}
+	}
+	return 0, fmt.Errorf("missing shard_key field in %s", v.Elem().Type())
+}
+
+// Shard returns the database of the shard of key.
+func (s *ShardedDB) Shard(key interface{}) (*DB, error) {
+	i, err := s.index(key)
+	if err != nil {
+		return nil, err
+	}
+	return s.shards[i], nil
+}
+
+// ShardOf returns the database of the shard of the struct value.
+func (s *ShardedDB) ShardOf(value interface{}) (*DB, error) {
+	i, err := s.indexOf(value)
+	if err != nil {
+		return nil, err
+	}
+	return s.shards[i], nil
+}
+
+// InsertContext insert a struct to the database of its shard
+func (s *ShardedDB) InsertContext(ctx context.Context, value interface{}) (sql.Result, error) {
+	db, err := s.ShardOf(value)
+	if err != nil {
+		return nil, err
+	}
+	return db.InsertContext(ctx, value)
+}
+
+// Insert a struct to the database of its shard
+func (s *ShardedDB) Insert(value interface{}) (sql.Result, error) {
+	return s.InsertContext(context.Background(), value)
+}
+
+// UpdateContext update a struct in the database of its shard
+func (s *ShardedDB) UpdateContext(ctx context.Context, value interface{}, columns ...string) (sql.Result, error) {
+	db, err := s.ShardOf(value)
+	if err != nil {
+		return nil, err
+	}
+	return db.UpdateContext(ctx, value, columns...)
+}
+
+// Update a struct in the database of its shard
+func (s *ShardedDB) Update(value interface{}, columns ...string) (sql.Result, error) {
+	return s.UpdateContext(context.Background(), value, columns...)
+}
+
+// FindContext reloads the struct dest from the database of its shard by its primary key,
+// the shard key and the primary key of dest must be set.
+// An sql.ErrNoRows is returned if the row does not exist. The relations of preload are loaded from the same shard.
+func (s *ShardedDB) FindContext(ctx context.Context, dest interface{}, preload ...PreloadOption) error {
+	db, err := s.ShardOf(dest)
+	if err != nil {
+		return err
+	}
+	return db.find(ctx, dest, preload)
+}
+
+// Find reloads the struct dest from the database of its shard by its primary key.
+func (s *ShardedDB) Find(dest interface{}, preload ...PreloadOption) error {
+	return s.FindContext(context.Background(), dest, preload...)
+}
+
+// QueryContext execute the query on every shard concurrently and scan the rows of all shards to dest,
+// dest must be a pointer to slice. The rows are appended in the order of the shards.
+func (s *ShardedDB) QueryContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return errors.New("dest must be a pointer to slice")
+	}
+	results := make([]reflect.Value, len(s.shards))
+	errs := make([]error, len(s.shards))
+	var wg sync.WaitGroup
+	for i, db := range s.shards {
+		results[i] = reflect.New(rv.Elem().Type())
+		wg.Add(1)
+		go func(i int, db *DB) {
+			defer wg.Done()
+			errs[i] = db.QueryContext(ctx, results[i].Interface(), query, args...)
+		}(i, db)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("shard %d: %w", i, err)
+		}
+	}
+	merged := rv.Elem().Slice(0, 0)
+	for _, result := range results {
+		merged = reflect.AppendSlice(merged, result.Elem())
+	}
+	rv.Elem().Set(merged)
+	return nil
+}
+
+// Query execute the query on every shard and scan the rows of all shards to dest.
+func (s *ShardedDB) Query(dest interface{}, query string, args ...interface{}) error {
+	return s.QueryContext(context.Background(), dest, query, args...)
+}
+
+// BeginTx begin a transaction on the shard of key.
+func (s *ShardedDB) BeginTx(ctx context.Context, key interface{}, opts *sql.TxOptions) (*ShardedTx, error) {
+	i, err := s.index(key)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := s.shards[i].BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &ShardedTx{tx: tx, db: s, shard: i}, nil
+}
+
+// Transaction begin a transaction on the shard of key and commit automatically,
+// automatically roll back when there is an error.
+func (s *ShardedDB) Transaction(key interface{}, fn func(*ShardedTx) error) error {
+	i, err := s.index(key)
+	if err != nil {
+		return err
+	}
+	return s.shards[i].Transaction(func(tx *Tx) error {
+		return fn(&ShardedTx{tx: tx, db: s, shard: i})
+	})
+}
+
+// Close closes the databases of all shards.
+func (s *ShardedDB) Close() error {
+	var err error
+	for _, db := range s.shards {
+		if e := db.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// ShardedTx is a transaction on a single shard of a ShardedDB,
+// Insert, Update and Find return ErrCrossShard for a struct of another shard.
+// The statements of Exec, Get and Query are executed on the shard of the transaction as is.
+type ShardedTx struct {
+	tx    *Tx
+	db    *ShardedDB
+	shard int
+}
+
+// Shard returns the index of the shard of the transaction.
+func (t *ShardedTx) Shard() int {
+	return t.shard
+}
+
+// Commit the transaction, see Tx.Commit.
+func (t *ShardedTx) Commit() error {
+	return t.tx.Commit()
+}
+
+// Rollback the transaction, see Tx.Rollback.
+func (t *ShardedTx) Rollback() error {
+	return t.tx.Rollback()
+}
+
+// OnCommit registers fn to be called after the transaction has been committed, see Tx.OnCommit.
+func (t *ShardedTx) OnCommit(fn func() error) {
+	t.tx.OnCommit(fn)
+}
+
+// OnRollback registers fn to be called after the transaction has been rolled back, see Tx.OnRollback.
+func (t *ShardedTx) OnRollback(fn func() error) {
+	t.tx.OnRollback(fn)
+}
+
+// ExecContext executes a query on the shard of the transaction without returning any rows.
+func (t *ShardedTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return t.tx.ExecContext(ctx, query, args...)
+}
+
+// Exec executes a query on the shard of the transaction without returning any rows.
+func (t *ShardedTx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return t.ExecContext(context.Background(), query, args...)
+}
+
+// GetContext execute the query on the shard of the transaction and scan the first row to dest like Tx.GetContext.
+func (t *ShardedTx) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return t.tx.GetContext(ctx, dest, query, args...)
+}
+
+// Get execute the query on the shard of the transaction and scan the first row to dest.
+func (t *ShardedTx) Get(dest interface{}, query string, args ...interface{}) error {
+	return t.GetContext(context.Background(), dest, query, args...)
+}
+
+// QueryContext execute the query on the shard of the transaction and scan the rows to dest like Tx.QueryContext.
+func (t *ShardedTx) QueryContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return t.tx.QueryContext(ctx, dest, query, args...)
+}
+
+// Query execute the query on the shard of the transaction and scan the rows to dest.
+func (t *ShardedTx) Query(dest interface{}, query string, args ...interface{}) error {
+	return t.QueryContext(context.Background(), dest, query, args...)
+}
+
+func (t *ShardedTx) check(value interface{}) error {
+	i, err := t.db.indexOf(value)
+	if err != nil {
+		return err
+	}
+	if i != t.shard {
+		return fmt.Errorf("%w: value of shard %d in transaction of shard %d", ErrCrossShard, i, t.shard)
+	}
+	return nil
+}
+
+// InsertContext insert a struct of the shard of the transaction
+func (t *ShardedTx) InsertContext(ctx context.Context, value interface{}) (sql.Result, error) {
+	if err := t.check(value); err != nil {
+		return nil, err
+	}
+	return t.tx.InsertContext(ctx, value)
+}
+
+// Insert a struct of the shard of the transaction
+func (t *ShardedTx) Insert(value interface{}) (sql.Result, error) {
+	return t.InsertContext(context.Background(), value)
+}
+
+// UpdateContext update a struct of the shard of the transaction
+func (t *ShardedTx) UpdateContext(ctx context.Context, value interface{}, columns ...string) (sql.Result, error) {
+	if err := t.check(value); err != nil {
+		return nil, err
+	}
+	return t.tx.UpdateContext(ctx, value, columns...)
+}
+
+// Update a struct of the shard of the transaction
+func (t *ShardedTx) Update(value interface{}, columns ...string) (sql.Result, error) {
+	return t.UpdateContext(context.Background(), value, columns...)
+}
+
+// FindContext reloads a struct of the shard of the transaction by its primary key.
+func (t *ShardedTx) FindContext(ctx context.Context, dest interface{}, preload ...PreloadOption) error {
+	if err := t.check(dest); err != nil {
+		return err
+	}
+	return t.tx.find(ctx, dest, preload)
+}
+
+// Find reloads a struct of the shard of the transaction by its primary key.
+func (t *ShardedTx) Find(dest interface{}, preload ...PreloadOption) error {
+	return t.FindContext(context.Background(), dest, preload...)
+}
+
+// find selects the row of the struct dest by its primary key and scans it to dest,
+// then loads the relations of preload.
+func (e *executor) find(ctx context.Context, dest interface{}, preload []PreloadOption) error {
+	table := e.structTable(ctx, dest)
+	if table == "" {
+		return fmt.Errorf("%T not implement Table interface", dest)
+	}
+	props := map[string]reflectx.Property{}
+	reflectx.ReflectProperty(reflect.ValueOf(dest), props)
+	for column, prop := range props {
+		if prop.Tag.PrimaryKey {
+			query := "select * from " + e.option.quoteTable(table) + " where " + column + " = ?"
+			args := []interface{}{fieldValue(*prop.Value)}
+			for _, opt := range preload {
+				args = append(args, opt)
+			}
+			return e.GetContext(withTable(ctx, table), dest, query, args...)
+		}
+	}
+	return fmt.Errorf("missing primary key in %T", dest)
+}
@@ -0,0 +1,154 @@
+package dbx
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+type tenantAccount struct {
+	ID       int64  `dbx:"column:id;primary_key;auto_increment"`
+	Tenant   int64  `dbx:"column:tenant_id;shard_key"`
+	NickName string `dbx:"column:nickname"`
+}
+
+func (*tenantAccount) TableName() string {
+	return "tenant_accounts"
+}
+
+func openShards(t *testing.T, fn ShardFunc) *ShardedDB {
+	shards := NewShardedDB(fn, openSQLite(t), openSQLite(t))
+	for _, db := range shards.Shards() {
+		db.MustExec("create table tenant_accounts(id integer primary key autoincrement, tenant_id integer, nickname varchar(24))")
+	}
+	return shards
+}
+
+func TestShardedDB(t *testing.T) {
+	shards := openShards(t, func(key interface{}) int {
+		return int(key.(int64) % 2)
+	})
+
+	for i, name := range []string{"a", "b", "c"} {
+		if _, err := shards.Insert(&tenantAccount{Tenant: int64(i), NickName: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var count int
+	shards.Shards()[0].MustGet(&count, "select count(*) from tenant_accounts")
+	if count != 2 {
+		t.Errorf("shard 0 count=%d", count)
+	}
+
+	found := &tenantAccount{ID: 1, Tenant: 1}
+	if err := shards.Find(found); err != nil {
+		t.Fatal(err)
+	}
+	if found.NickName != "b" {
+		t.Errorf("found=%+v", found)
+	}
+	found.NickName = "bb"
+	if _, err := shards.Update(found); err != nil {
+		t.Fatal(err)
+	}
+	if err := shards.Find(&tenantAccount{ID: 2, Tenant: 1}); !IsNotFound(err) {
+		t.Errorf("find missing row err=%v", err)
+	}
+
+	var all []tenantAccount
+	if err := shards.Query(&all, "select * from tenant_accounts order by id"); err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 || all[0].NickName != "a" || all[1].NickName != "c" || all[2].NickName != "bb" {
+		t.Errorf("query=%+v", all)
+	}
+
+	if _, err := shards.Insert(&accountRecord{}); err == nil {
+		t.Error("insert without shard_key")
+	}
+	if _, err := shards.Shard(int64(-1)); err == nil {
+		t.Error("shard out of range")
+	}
+}
+
+func TestShardedDB_Transaction(t *testing.T) {
+	shards := openShards(t, HashShard(2))
+	var same, other int64
+	for other = 1; HashShard(2)(other) == HashShard(2)(same); other++ {
+	}
+	err := shards.Transaction(same, func(tx *ShardedTx) error {
+		if _, err := tx.Insert(&tenantAccount{Tenant: same, NickName: "a"}); err != nil {
+			return err
+		}
+		_, err := tx.Insert(&tenantAccount{Tenant: other, NickName: "b"})
+		return err
+	})
+	if !errors.Is(err, ErrCrossShard) {
+		t.Fatalf("err=%v", err)
+	}
+	var all []tenantAccount
+	if err = shards.Query(&all, "select * from tenant_accounts"); err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 0 {
+		t.Errorf("rolled back rows=%+v", all)
+	}
+}
+
+func TestHashShard(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for 0 shards")
+		}
+	}()
+	HashShard(0)
+}
+
+type nullTenantAccount struct {
+	ID       int64         `dbx:"column:id;primary_key;auto_increment"`
+	Tenant   sql.NullInt64 `dbx:"column:tenant_id;shard_key"`
+	NickName string        `dbx:"column:nickname"`
+}
+
+func (*nullTenantAccount) TableName() string {
+	return "tenant_accounts"
+}
+
+type ptrTenantAccount struct {
+	ID       int64  `dbx:"column:id;primary_key;auto_increment"`
+	Tenant   *int64 `dbx:"column:tenant_id;shard_key"`
+	NickName string `dbx:"column:nickname"`
+}
+
+func (*ptrTenantAccount) TableName() string {
+	return "tenant_accounts"
+}
+
+func TestShardedDB_ShardKey(t *testing.T) {
+	hash := HashShard(2)
+	shards := openShards(t, hash)
+	want, _ := shards.Shard(int64(42))
+	if want != shards.Shards()[hash(int64(42))] {
+		t.Fatal("unexpected shard of 42")
+	}
+	tenant := int64(42)
+	for _, value := range []interface{}{
+		&nullTenantAccount{Tenant: sql.NullInt64{Int64: 42, Valid: true}},
+		&ptrTenantAccount{Tenant: &tenant},
+	} {
+		if db, err := shards.ShardOf(value); err != nil || db != want {
+			t.Fatalf("shard of %+v: %v", value, err)
+		}
+	}
+	if db, err := shards.Shard(sql.NullInt64{Int64: 42, Valid: true}); err != nil || db != want {
+		t.Fatalf("shard of sql.NullInt64: %v", err)
+	}
+	for _, value := range []interface{}{&nullTenantAccount{}, &ptrTenantAccount{}} {
+		if _, err := shards.Insert(value); err == nil {
+			t.Fatalf("expected an error for the NULL shard key of %+v", value)
+		}
+	}
+	if _, err := shards.Shard(nil); err == nil {
+		t.Fatal("expected an error for a nil shard key")
+	}
+}
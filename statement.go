@@ -12,14 +12,22 @@ type Stmt struct {
 	rawQuery string
 	stmt     *sql.Stmt
 	option   *Options
-}
+	inTx     bool
 
-func newStmt(preparer preparer, query string, option *Options) (stmt *Stmt, err error) {
-	return newStmtContext(context.Background(), preparer, query, option)
+	// direct is used instead of stmt when the statement is not prepared.
+	direct preparer
+	// cached is the entry of stmt in cache, the statement is released instead of closed.
+	cached *cachedStmt
+	cache  *stmtCache
 }
 
-func newStmtContext(ctx context.Context, preparer preparer, query string, option *Options) (stmt *Stmt, err error) {
-	s, err := preparer.PrepareContext(ctx, query)
+func newStmtContext(ctx context.Context, preparer preparer, query string, option *Options, inTx bool) (stmt *Stmt, err error) {
+	var s *sql.Stmt
+	inv := &Invocation{Op: OpPrepare, SQL: query, Table: tableFromContext(ctx), InTx: inTx, RowsAffected: -1}
+	err = option.intercept(ctx, inv, func(ctx context.Context, inv *Invocation) (err error) {
+		s, err = preparer.PrepareContext(ctx, query)
+		return option.translateError(err)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -27,6 +35,7 @@ func newStmtContext(ctx context.Context, preparer preparer, query string, option
 		stmt:     s,
 		rawQuery: query,
 		option:   option,
+		inTx:     inTx,
 	}, nil
 }
 
@@ -51,21 +60,50 @@ func (s *Stmt) format(ctx context.Context, args ...interface{}) []interface{} {
 	if s.option.TimeFormat != nil {
 		args = timeFormat(s.option.TimeFormat, args...)
 	}
-	printSQL(s.rawQuery, args, s.option)
 	return args
 }
 
-func (s *Stmt) GetContext(ctx context.Context, dest interface{}, args ...interface{}) error {
-	args = s.format(ctx, args...)
-	rows, err := s.stmt.QueryContext(ctx, args...)
-	if err != nil {
-		return err
+func (s *Stmt) queryContext(ctx context.Context, args ...interface{}) (*sql.Rows, error) {
+	if s.stmt == nil {
+		return s.direct.QueryContext(ctx, s.rawQuery, args...)
 	}
-	err = mapping(rows, dest, single)
-	if err != nil {
-		return err
+	return s.stmt.QueryContext(ctx, args...)
+}
+
+func (s *Stmt) execContext(ctx context.Context, args ...interface{}) (sql.Result, error) {
+	if s.stmt == nil {
+		return s.direct.ExecContext(ctx, s.rawQuery, args...)
 	}
-	return err
+	return s.stmt.ExecContext(ctx, args...)
+}
+
+// run executes fn through the interceptors and logs the statement.
+// fn returns the number of rows affected or scanned.
+func (s *Stmt) run(ctx context.Context, op Operation, args []interface{}, fn func(ctx context.Context) (int64, error)) error {
+	start := time.Now()
+	inv := &Invocation{Op: op, SQL: s.rawQuery, Args: args, Table: tableFromContext(ctx), InTx: s.inTx, RowsAffected: -1}
+	err := s.option.intercept(ctx, inv, func(ctx context.Context, inv *Invocation) (err error) {
+		inv.RowsAffected, err = fn(ctx)
+		return s.option.translateError(err)
+	})
+	s.log(ctx, start, args, inv.RowsAffected, err)
+	return s.option.queryError(ctx, op, s.rawQuery, args, err)
+}
+
+// log sends the statement to the loggers of options after it has been executed.
+func (s *Stmt) log(ctx context.Context, start time.Time, args []interface{}, rows int64, err error) {
+	logQuery(ctx, s.option, s.rawQuery, args, start, rows, err, s.inTx)
+}
+
+func (s *Stmt) GetContext(ctx context.Context, dest interface{}, args ...interface{}) error {
+	args = s.format(ctx, args...)
+	return s.run(ctx, OpQuery, args, func(ctx context.Context) (int64, error) {
+		rows, err := s.queryContext(ctx, args...)
+		if err != nil {
+			return -1, err
+		}
+		return mapping(rows, dest, single)
+	})
 }
 
 func (s *Stmt) Get(dest interface{}, args ...interface{}) error {
@@ -74,15 +112,13 @@ func (s *Stmt) Get(dest interface{}, args ...interface{}) error {
 
 func (s *Stmt) QueryContext(ctx context.Context, dest interface{}, args ...interface{}) error {
 	args = s.format(ctx, args...)
-	rows, err := s.stmt.QueryContext(ctx, args...)
-	if err != nil {
-		return err
-	}
-	err = mapping(rows, dest, slice)
-	if err != nil {
-		return err
-	}
-	return err
+	return s.run(ctx, OpQuery, args, func(ctx context.Context) (int64, error) {
+		rows, err := s.queryContext(ctx, args...)
+		if err != nil {
+			return -1, err
+		}
+		return mapping(rows, dest, slice)
+	})
 }
 
 func (s *Stmt) Query(dest interface{}, args ...interface{}) error {
@@ -91,7 +127,18 @@ func (s *Stmt) Query(dest interface{}, args ...interface{}) error {
 
 func (s *Stmt) ExecContext(ctx context.Context, args ...interface{}) (sql.Result, error) {
 	args = s.format(ctx, args...)
-	return s.stmt.ExecContext(ctx, args...)
+	var rs sql.Result
+	err := s.run(ctx, OpExec, args, func(ctx context.Context) (n int64, err error) {
+		rs, err = s.execContext(ctx, args...)
+		if err != nil {
+			return -1, err
+		}
+		if n, err = rs.RowsAffected(); err != nil {
+			return -1, nil
+		}
+		return n, nil
+	})
+	return rs, err
 }
 
 func (s *Stmt) Exec() (sql.Result, error) {
@@ -99,5 +146,11 @@ func (s *Stmt) Exec() (sql.Result, error) {
 }
 
 func (s *Stmt) Close() error {
+	if s.cached != nil {
+		return s.cache.release(s.cached)
+	}
+	if s.stmt == nil {
+		return nil
+	}
 	return s.stmt.Close()
 }
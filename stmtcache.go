@@ -0,0 +1,102 @@
+package dbx
+
+import (
+	"container/list"
+	"database/sql"
+	"sync"
+)
+
+// DefaultStmtCacheSize is the Options.StmtCacheSize set by Open.
+const DefaultStmtCacheSize = 64
+
+// cachedStmt is a prepared statement in a stmtCache,
+// it is closed once it has been evicted and is no longer used.
+type cachedStmt struct {
+	query   string
+	stmt    *sql.Stmt
+	refs    int
+	evicted bool
+}
+
+// stmtCache is a LRU cache of prepared statements keyed by query.
+type stmtCache struct {
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+func newStmtCache() *stmtCache {
+	return &stmtCache{lru: list.New(), entries: map[string]*list.Element{}}
+}
+
+// get returns the cached statement of query, the caller must release it.
+func (c *stmtCache) get(query string) *cachedStmt {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[query]
+	if !ok {
+		return nil
+	}
+	c.lru.MoveToFront(el)
+	cs := el.Value.(*cachedStmt)
+	cs.refs++
+	return cs
+}
+
+// put caches stmt for query and evicts the least recently used statements to keep at most size statements.
+// It returns nil if the statement is not cached, the caller owns the statement then.
+// Otherwise the caller must release the returned statement.
+func (c *stmtCache) put(query string, stmt *sql.Stmt, size int) *cachedStmt {
+	if size <= 0 {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[query]; ok {
+		return nil
+	}
+	cs := &cachedStmt{query: query, stmt: stmt, refs: 1}
+	c.entries[query] = c.lru.PushFront(cs)
+	for c.lru.Len() > size {
+		c.evict(c.lru.Back())
+	}
+	return cs
+}
+
+// release gives back a statement returned by get or put.
+func (c *stmtCache) release(cs *cachedStmt) error {
+	c.mu.Lock()
+	cs.refs--
+	closable := cs.evicted && cs.refs == 0
+	c.mu.Unlock()
+	if closable {
+		return cs.stmt.Close()
+	}
+	return nil
+}
+
+// evict removes el from the cache, the statement is closed when it is unused.
+func (c *stmtCache) evict(el *list.Element) {
+	cs := c.lru.Remove(el).(*cachedStmt)
+	delete(c.entries, cs.query)
+	cs.evicted = true
+	if cs.refs == 0 {
+		_ = cs.stmt.Close()
+	}
+}
+
+// len returns the number of cached statements.
+func (c *stmtCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// close evicts all statements.
+func (c *stmtCache) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.lru.Len() > 0 {
+		c.evict(c.lru.Back())
+	}
+}
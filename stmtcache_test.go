@@ -0,0 +1,113 @@
+package dbx
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestStmtCache(t *testing.T) {
+	db := openSQLite(t)
+	db.Options().StmtCacheSize = 2
+	rec := NewRecorder()
+	db.Options().Interceptors = []Interceptor{rec.Intercept}
+
+	for i := 0; i < 3; i++ {
+		db.MustExec("update accounts set status=?", i)
+	}
+	db.MustExec("update accounts set uid=?", 1)
+	db.MustExec("update accounts set avatar=?", "a")
+	db.MustExec("update accounts set status=?", 1)
+
+	prepared := 0
+	for _, r := range rec.Records() {
+		if r.Op == OpPrepare {
+			prepared++
+		}
+	}
+	if prepared != 4 {
+		t.Fatalf("expected 4 prepares, got %d", prepared)
+	}
+	if db.cache.len() != 2 {
+		t.Fatalf("expected 2 cached statements, got %d", db.cache.len())
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.MustExec("update accounts set status=?", 2)
+	if tx.cache.len() != 1 {
+		t.Fatalf("expected 1 cached statement in tx, got %d", tx.cache.len())
+	}
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if tx.cache.len() != 0 {
+		t.Fatalf("expected tx cache closed, got %d", tx.cache.len())
+	}
+}
+
+func TestOptions_NoPrepare(t *testing.T) {
+	db := openSQLite(t)
+	db.Options().NoPrepare = true
+	rec := NewRecorder()
+	db.Options().Interceptors = []Interceptor{rec.Intercept}
+	db.MustInsert(&accountRecord{Status: 1})
+	var ids []int64
+	db.MustQuery(&ids, "select id from accounts where status=?", 1)
+	if len(ids) != 1 {
+		t.Fatalf("unexpected ids:%v", ids)
+	}
+	for _, r := range rec.Records() {
+		if r.Op == OpPrepare {
+			t.Fatalf("unexpected prepare:%+v", r)
+		}
+	}
+}
+
+func benchmarkGet(b *testing.B, configure func(o *Options)) {
+	db := openSQLite(b)
+	configure(db.Options())
+	for i := 0; i < 100; i++ {
+		db.MustInsert(&accountRecord{Status: i})
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var status int
+		if err := db.Get(&status, "select status from accounts where id=?", i%100+1); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGet(b *testing.B) {
+	modes := []struct {
+		name      string
+		configure func(o *Options)
+	}{
+		{"PreparePerCall", func(o *Options) { o.StmtCacheSize = 0 }},
+		{"StmtCache", func(o *Options) { o.StmtCacheSize = DefaultStmtCacheSize }},
+		{"NoPrepare", func(o *Options) { o.NoPrepare = true }},
+	}
+	for _, mode := range modes {
+		b.Run(mode.name, func(b *testing.B) {
+			benchmarkGet(b, mode.configure)
+		})
+	}
+}
+
+func BenchmarkStmtCache_Concurrent(b *testing.B) {
+	db := openSQLite(b)
+	db.Options().StmtCacheSize = 4
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			var n int
+			query := fmt.Sprintf("select count(*) from accounts where status>%d", i%8)
+			if err := db.Get(&n, query); err != nil {
+				b.Fatal(err)
+			}
+			i++
+		}
+	})
+}
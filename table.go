@@ -1,6 +1,131 @@
 package dbx
 
-//Table is a interface with TableName
+import (
+	"context"
+	"strings"
+)
+
+// Table is a interface with TableName
 type Table interface {
 	TableName() string
 }
+
+// TableContext is implemented by a struct whose table depends on the context,
+// like a table per tenant or per month. It takes precedence over Table.
+type TableContext interface {
+	TableNameContext(ctx context.Context) string
+}
+
+type tableNameContextKey struct{}
+
+// WithTableName returns a copy of ctx whose struct operations like Insert, Update and Seek
+// use the table name instead of the table of the struct. Options.TablePrefix is not added to name,
+// which may be qualified by a schema like schema.table.
+func WithTableName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, tableNameContextKey{}, name)
+}
+
+// tableName returns the table name of value, or empty string if value does not implement Table.
+func tableName(value interface{}) string {
+	if tv, ok := value.(Table); ok {
+		return tv.TableName()
+	}
+	return ""
+}
+
+// tableOf returns the unquoted table name of value in ctx with the TablePrefix of options,
+// or empty string if value implements neither TableContext nor Table.
+func (o *Options) tableOf(ctx context.Context, value interface{}) string {
+	name := ""
+	if tv, ok := value.(TableContext); ok {
+		name = tv.TableNameContext(ctx)
+	} else {
+		name = tableName(value)
+	}
+	if name == "" || o.TablePrefix == "" {
+		return name
+	}
+	i := strings.LastIndex(name, ".")
+	return name[:i+1] + o.TablePrefix + name[i+1:]
+}
+
+// structTable is tableOf unless the table is overridden by WithTableName.
+func (o *Options) structTable(ctx context.Context, value interface{}) string {
+	if name, ok := ctx.Value(tableNameContextKey{}).(string); ok && name != "" {
+		return name
+	}
+	return o.tableOf(ctx, value)
+}
+
+// Table returns an ExtendedExecutor whose struct operations like Insert, Update and Seek use the table name
+// instead of the table of the struct, for example:
+//
+//	db.Table("events_2026_10").Insert(&event)
+//
+// Options.TablePrefix is not added to name, which may be qualified by a schema like schema.table.
+func (e *executor) Table(name string) ExtendedExecutor {
+	exec := *e
+	exec.table = name
+	return &exec
+}
+
+// structTable is the table of the struct value in the struct operations of e.
+func (e *executor) structTable(ctx context.Context, value interface{}) string {
+	if e.table != "" {
+		return e.table
+	}
+	return e.option.structTable(ctx, value)
+}
+
+// tableParts splits a table name qualified by a schema on the dots which are not quoted.
+func tableParts(name string) []string {
+	var parts []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(name); i++ {
+		switch c := name[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '`' || c == '"':
+			quote = c
+		case c == '.':
+			parts = append(parts, name[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, name[start:])
+}
+
+// quoteTable quotes every part of a table name which may be qualified by a schema,
+// the name is not quoted without a dialect.
+func (o *Options) quoteTable(name string) string {
+	if o.Dialect == nil {
+		return name
+	}
+	parts := tableParts(name)
+	for i, part := range parts {
+		if strings.HasPrefix(part, "`") || strings.HasPrefix(part, `"`) {
+			continue
+		}
+		parts[i] = o.Dialect.Quote(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+// quoteIdentifier quotes a column name with the dialect, it is not quoted without a dialect.
+func (o *Options) quoteIdentifier(name string) string {
+	if o.Dialect == nil {
+		return name
+	}
+	return o.Dialect.Quote(name)
+}
+
+func (mysqlDialect) Quote(identifier string) string {
+	return "`" + strings.Replace(identifier, "`", "``", -1) + "`"
+}
+
+func (sqliteDialect) Quote(identifier string) string {
+	return `"` + strings.Replace(identifier, `"`, `""`, -1) + `"`
+}
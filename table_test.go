@@ -0,0 +1,100 @@
+package dbx
+
+import (
+	"context"
+	"testing"
+)
+
+type tenantContextKey struct{}
+
+type order struct {
+	ID   int64  `dbx:"column:id;primary_key;auto_increment"`
+	Item string `dbx:"column:item"`
+}
+
+func (*order) TableNameContext(ctx context.Context) string {
+	return ctx.Value(tenantContextKey{}).(string) + "_orders"
+}
+
+func TestTableContext(t *testing.T) {
+	db := openSQLite(t)
+	for _, table := range []string{"t1_orders", "t2_orders", "app_t1_orders", "archived_orders"} {
+		db.MustExec("create table " + table + "(id integer primary key autoincrement, item varchar(24))")
+	}
+	count := func(table string) (n int) {
+		db.MustGet(&n, "select count(*) from "+table)
+		return
+	}
+	ctx := context.WithValue(context.Background(), tenantContextKey{}, "t1")
+	o := &order{Item: "book"}
+	if _, err := db.InsertContext(ctx, o); err != nil {
+		t.Fatal(err)
+	}
+	o.Item = "pen"
+	if _, err := db.UpdateContext(ctx, o); err != nil {
+		t.Fatal(err)
+	}
+	var item string
+	db.MustGet(&item, "select item from t1_orders where id = ?", o.ID)
+	if item != "pen" || count("t2_orders") != 0 {
+		t.Errorf("t1 item=%s, t2 count=%d", item, count("t2_orders"))
+	}
+	var orders []order
+	if _, err := db.Seek(ctx, &orders, "", Keyset{OrderBy: []string{"id"}, Size: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 1 {
+		t.Errorf("seek=%+v", orders)
+	}
+
+	db.Options().TablePrefix = "app_"
+	if _, err := db.InsertContext(ctx, &order{Item: "cup"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.InsertContext(WithTableName(ctx, "main.archived_orders"), &order{Item: "old"}); err != nil {
+		t.Fatal(err)
+	}
+	if count("app_t1_orders") != 1 || count("archived_orders") != 1 || count("t1_orders") != 1 {
+		t.Errorf("counts=%d,%d,%d", count("app_t1_orders"), count("archived_orders"), count("t1_orders"))
+	}
+
+	archived := &order{Item: "lamp"}
+	db.Table("archived_orders").MustInsert(archived)
+	archived.Item = "desk"
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tx.Table("main.archived_orders").Update(archived); err != nil {
+		t.Fatal(err)
+	}
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	db.MustGet(&item, "select item from archived_orders where id = ?", archived.ID)
+	if item != "desk" || count("archived_orders") != 2 || count("app_t1_orders") != 1 {
+		t.Errorf("archived item=%s, counts=%d,%d", item, count("archived_orders"), count("app_t1_orders"))
+	}
+}
+
+func TestOptions_quoteTable(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		name    string
+		want    string
+	}{
+		{MySQL, "orders", "`orders`"},
+		{MySQL, "shop.orders", "`shop`.`orders`"},
+		{nil, "shop.orders", "shop.orders"},
+		{SQLite, "main.orders", `"main"."orders"`},
+		{SQLite, `main."order"`, `"main"."order"`},
+		{SQLite, `"my.schema".orders`, `"my.schema"."orders"`},
+		{MySQL, "`my.schema`.`order.v2`", "`my.schema`.`order.v2`"},
+	}
+	for _, tt := range tests {
+		o := &Options{Dialect: tt.dialect}
+		if got := o.quoteTable(tt.name); got != tt.want {
+			t.Errorf("quoteTable(%s)=%s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
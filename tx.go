@@ -1,24 +1,195 @@
 package dbx
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
 )
 
 type Tx struct {
 	*executor
-	tx *sql.Tx
+	tx  *sql.Tx
+	ctx context.Context
+
+	// endMu serializes Commit and Rollback, done is set once the driver has ended the transaction.
+	endMu sync.Mutex
+	done  bool
+
+	mu         sync.Mutex
+	onCommit   []func() error
+	onRollback []func() error
+}
+
+func newTx(ctx context.Context, tx *sql.Tx, option *Options) *Tx {
+	exec := newDefaultExecutor(tx, option)
+	exec.inTx = true
+	return &Tx{executor: exec, tx: tx, ctx: ctx}
 }
 
-func newTx(tx *sql.Tx, option *Options) *Tx {
-	return &Tx{executor: newDefaultExecutor(tx, option), tx: tx}
+// end commits or rolls back the transaction through the interceptors,
+// a finished transaction returns sql.ErrTxDone without calling them again.
+// The transaction is rolled back if an interceptor returns without calling fn,
+// so that its connection is released. rolledBack reports whether the transaction has been rolled back.
+func (t *Tx) end(op Operation, fn func() error) (rolledBack bool, err error) {
+	t.endMu.Lock()
+	defer t.endMu.Unlock()
+	if t.done {
+		return false, sql.ErrTxDone
+	}
+	called := false
+	var driverErr error
+	inv := &Invocation{Op: op, InTx: true, RowsAffected: -1}
+	err = t.option.intercept(t.ctx, inv, func(ctx context.Context, inv *Invocation) error {
+		called = true
+		driverErr = fn()
+		return t.option.translateError(driverErr)
+	})
+	if called {
+		rolledBack = op == OpRollback && driverErr == nil
+	} else {
+		rolledBack = t.tx.Rollback() == nil
+	}
+	t.done = true
+	return rolledBack, err
 }
 
-//Commit the transaction
+// OnCommit registers fn to be called after the transaction has been committed.
+// Callbacks run in the order they were registered and are discarded if the commit fails
+// or the transaction is rolled back.
+func (t *Tx) OnCommit(fn func() error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.onCommit = append(t.onCommit, fn)
+}
+
+// OnRollback registers fn to be called after the transaction has been rolled back,
+// including a commit rejected by an interceptor.
+// Callbacks run in the order they were registered and are discarded if the rollback fails,
+// the commit fails in the driver or the transaction is committed.
+func (t *Tx) OnRollback(fn func() error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.onRollback = append(t.onRollback, fn)
+}
+
+// callbacks returns the registered callbacks and forgets all of them,
+// so that every callback runs at most once.
+func (t *Tx) callbacks(commit bool) []func() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fns := t.onRollback
+	if commit {
+		fns = t.onCommit
+	}
+	t.onCommit, t.onRollback = nil, nil
+	return fns
+}
+
+// Commit the transaction.
+// If the transaction has been committed but an OnCommit callback failed, a *CallbackError is returned.
+// If the commit is rejected by an interceptor, the transaction is rolled back and a failing OnRollback callback
+// is reported by a *CallbackError caused by the commit error.
 func (t *Tx) Commit() error {
-	return t.tx.Commit()
+	rolledBack, err := t.end(OpCommit, t.tx.Commit)
+	t.cache.close()
+	if err != nil {
+		return t.rolledBack(rolledBack, err)
+	}
+	return runCallbacks(t.callbacks(true))
 }
 
-//Rollback the transaction
+// Rollback the transaction.
+// If the transaction has been rolled back but an OnRollback callback failed, a *CallbackError is returned.
 func (t *Tx) Rollback() error {
-	return t.tx.Rollback()
+	rolledBack, err := t.end(OpRollback, t.tx.Rollback)
+	t.cache.close()
+	return t.rolledBack(rolledBack, err)
+}
+
+// rolledBack runs the OnRollback callbacks if the transaction has been rolled back and returns err,
+// or a *CallbackError caused by err if a callback failed.
+func (t *Tx) rolledBack(rolledBack bool, err error) error {
+	fns := t.callbacks(false)
+	if !rolledBack {
+		return err
+	}
+	cbErr := runCallbacks(fns)
+	if cbErr == nil {
+		return err
+	}
+	cbErr.(*CallbackError).Cause = err
+	return cbErr
+}
+
+// CallbackError is returned by Commit or Rollback when one or more of the registered callbacks
+// returned an error or panicked.
+type CallbackError struct {
+	// Cause is the error which rolled the transaction back, a failed commit or the error of the Transaction
+	// function, nil if the transaction itself finished successfully.
+	Cause  error
+	Errors []error
+}
+
+func (e *CallbackError) Error() string {
+	msg := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msg[i] = err.Error()
+	}
+	s := "transaction callback: " + strings.Join(msg, "; ")
+	if e.Cause != nil {
+		s = e.Cause.Error() + "; " + s
+	}
+	return s
+}
+
+// Is reports whether the cause or an error of the callbacks matches target, for errors.Is.
+func (e *CallbackError) Is(target error) bool {
+	for _, err := range e.errors() {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first of the cause and the errors of the callbacks that matches target, for errors.As.
+func (e *CallbackError) As(target interface{}) bool {
+	for _, err := range e.errors() {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *CallbackError) errors() []error {
+	if e.Cause == nil {
+		return e.Errors
+	}
+	return append([]error{e.Cause}, e.Errors...)
+}
+
+func runCallbacks(fns []func() error) error {
+	var errs []error
+	for _, fn := range fns {
+		if err := runCallback(fn); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return &CallbackError{Errors: errs}
+	}
+	return nil
+}
+
+func runCallback(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn()
 }
@@ -1,7 +1,9 @@
 package dbx
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"testing"
 )
 
@@ -31,3 +33,140 @@ func TestTx_Insert(t *testing.T) {
 
 	// rawTx.Get(&account, "select * from accounts")
 }
+
+func TestTx_OnCommit(t *testing.T) {
+	db := openSQLite(t)
+	var calls []string
+	err := db.Transaction(func(tx *Tx) error {
+		tx.OnCommit(func() error {
+			calls = append(calls, "commit1")
+			return nil
+		})
+		tx.OnCommit(func() error {
+			calls = append(calls, "commit2")
+			return nil
+		})
+		tx.OnRollback(func() error {
+			calls = append(calls, "rollback")
+			return nil
+		})
+		_, err := tx.Insert(&accountRecord{Status: 1})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 2 || calls[0] != "commit1" || calls[1] != "commit2" {
+		t.Fatalf("unexpected callbacks:%v", calls)
+	}
+}
+
+func TestTx_OnRollback(t *testing.T) {
+	db := openSQLite(t)
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calls []string
+	tx.OnCommit(func() error {
+		calls = append(calls, "commit")
+		return nil
+	})
+	tx.OnRollback(func() error {
+		calls = append(calls, "rollback")
+		panic("boom")
+	})
+	err = tx.Rollback()
+	var cbErr *CallbackError
+	if !errors.As(err, &cbErr) || len(cbErr.Errors) != 1 {
+		t.Fatalf("expected callback error, got:%v", err)
+	}
+	if len(calls) != 1 || calls[0] != "rollback" {
+		t.Fatalf("unexpected callbacks:%v", calls)
+	}
+	if err = tx.Commit(); err != sql.ErrTxDone {
+		t.Fatalf("expected ErrTxDone, got:%v", err)
+	}
+	if len(calls) != 1 {
+		t.Fatalf("callbacks must run at most once:%v", calls)
+	}
+}
+
+func TestDB_Transaction_OnRollbackError(t *testing.T) {
+	db := openSQLite(t)
+	errFn, errCallback := errors.New("fn"), errors.New("callback")
+	err := db.Transaction(func(tx *Tx) error {
+		tx.OnRollback(func() error {
+			return errCallback
+		})
+		return errFn
+	})
+	var cbErr *CallbackError
+	if !errors.As(err, &cbErr) || cbErr.Cause != errFn {
+		t.Fatalf("expected callback error caused by fn, got:%v", err)
+	}
+	if !errors.Is(err, errFn) || !errors.Is(err, errCallback) {
+		t.Fatalf("expected both errors to be reachable:%v", err)
+	}
+}
+
+func TestTx_CommitFailure(t *testing.T) {
+	db := openSQLite(t)
+	errCommit := errors.New("commit")
+	db.Options().Interceptors = []Interceptor{func(ctx context.Context, inv *Invocation, next Handler) error {
+		if inv.Op == OpCommit {
+			return errCommit
+		}
+		return next(ctx, inv)
+	}}
+	db.RawDB().SetMaxOpenConns(1)
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calls []string
+	tx.OnCommit(func() error {
+		calls = append(calls, "commit")
+		return nil
+	})
+	tx.OnRollback(func() error {
+		calls = append(calls, "rollback")
+		return nil
+	})
+	if err = tx.Commit(); err != errCommit {
+		t.Fatalf("expected the commit error, got:%v", err)
+	}
+	// the rejected transaction has been rolled back and its connection released.
+	if len(calls) != 1 || calls[0] != "rollback" {
+		t.Fatalf("unexpected callbacks:%v", calls)
+	}
+	if inUse := db.RawDB().Stats().InUse; inUse != 0 {
+		t.Fatalf("the connection of the transaction is in use:%d", inUse)
+	}
+	if err = tx.Rollback(); err != sql.ErrTxDone {
+		t.Fatalf("expected ErrTxDone, got:%v", err)
+	}
+	if len(calls) != 1 {
+		t.Fatalf("callbacks must run at most once:%v", calls)
+	}
+}
+
+func TestTx_RollbackFailure(t *testing.T) {
+	db := openSQLite(t)
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	called := false
+	tx.OnRollback(func() error {
+		called = true
+		return nil
+	})
+	// the driver transaction is ended behind the Tx, so its rollback fails.
+	if err = tx.tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if err = tx.Rollback(); err == nil || called {
+		t.Fatalf("callbacks must not run when nothing has been rolled back, err:%v", err)
+	}
+}